@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"web-server-go-docker/internal/apikey"
+
+	"gopkg.in/yaml.v3"
+)
+
+// runAPIKey обрабатывает подкоманды apikey generate и apikey validate
+func runAPIKey(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "Usage: server apikey <generate|validate> [flags]")
+		return 2
+	}
+
+	sub := args[0]
+	fs := flag.NewFlagSet("apikey "+sub, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	switch sub {
+	case "generate":
+		id := fs.String("id", "", "key id written to logs (required)")
+		owner := fs.String("owner", "", "service or team owning the key")
+		scopes := fs.String("scopes", "", "comma-separated scopes, * grants all")
+		expires := fs.Duration("expires", 0, "key lifetime, e.g. 2160h (0 - no expiry)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		return generateAPIKey(*id, *owner, *scopes, *expires, stdout, stderr)
+	case "validate":
+		file := fs.String("file", os.Getenv("API_KEYS_FILE"), "API keys file or directory (defaults to API_KEYS_FILE)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if *file == "" {
+			fmt.Fprintln(stderr, "API keys file is required (-file or API_KEYS_FILE)")
+			return 2
+		}
+		keys, err := apikey.Load(*file)
+		if err != nil {
+			fmt.Fprintf(stderr, "invalid API keys: %v\n", err)
+			return 1
+		}
+		now := time.Now()
+		for _, k := range keys {
+			if k.Expired(now) {
+				fmt.Fprintf(stdout, "warning: API key %s expired at %s\n", k.ID, k.ExpiresAt.Format(time.RFC3339))
+			}
+		}
+		fmt.Fprintf(stdout, "%d API keys are valid\n", len(keys))
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown apikey command %q\n", sub)
+		return 2
+	}
+}
+
+// generateAPIKey печатает новый ключ и запись для файла ключей с его хешем.
+// Сам ключ нигде не сохраняется: его нужно сразу передать владельцу.
+func generateAPIKey(id, owner, scopes string, expires time.Duration, stdout, stderr io.Writer) int {
+	k := apikey.Key{ID: id, Owner: owner}
+	if scopes != "" {
+		for _, scope := range strings.Split(scopes, ",") {
+			k.Scopes = append(k.Scopes, strings.TrimSpace(scope))
+		}
+	}
+	if expires > 0 {
+		k.ExpiresAt = time.Now().Add(expires).UTC().Truncate(time.Second)
+	}
+
+	raw, err := apikey.Generate()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	k.Hash = apikey.Hash(raw)
+	if err := apikey.Validate([]apikey.Key{k}); err != nil {
+		fmt.Fprintf(stderr, "invalid API key: %v\n", err)
+		return 2
+	}
+
+	entry, err := yaml.Marshal([]apikey.Key{k})
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to encode API key: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "# API key (shown once): %s\n", raw)
+	fmt.Fprintln(stdout, "# add the entry below to the keys list and reload the server with SIGHUP")
+	if _, err := stdout.Write(entry); err != nil {
+		return 1
+	}
+	return 0
+}
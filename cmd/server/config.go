@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// runConfig обрабатывает подкоманды config validate и config print
+func runConfig(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "Usage: server config <validate|print> [flags]")
+		return 2
+	}
+
+	sub := args[0]
+	fs := flag.NewFlagSet("config "+sub, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var flags configFlags
+	flags.register(fs)
+	format := "json"
+	if sub == "print" {
+		fs.StringVar(&format, "format", "json", "output format: json|text")
+	}
+
+	switch sub {
+	case "validate", "print":
+	default:
+		fmt.Fprintf(stderr, "unknown config command %q\n", sub)
+		return 2
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	cfg, err := flags.load()
+	if err != nil {
+		fmt.Fprintf(stderr, "invalid configuration: %v\n", err)
+		return 1
+	}
+
+	if sub == "validate" {
+		fmt.Fprintln(stdout, "configuration is valid")
+		return 0
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(cfg.Redacted()); err != nil {
+			fmt.Fprintf(stderr, "failed to encode config: %v\n", err)
+			return 1
+		}
+	case "text":
+		printFlat(stdout, "", cfg.Redacted())
+	default:
+		fmt.Fprintf(stderr, "unknown format %q\n", format)
+		return 2
+	}
+
+	return 0
+}
+
+// printFlat выводит вложенную конфигурацию в виде строк key.subkey = value
+func printFlat(w io.Writer, prefix string, values map[string]interface{}) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		name := strings.TrimPrefix(prefix+"."+k, ".")
+		if nested, ok := values[k].(map[string]interface{}); ok {
+			printFlat(w, name, nested)
+			continue
+		}
+		fmt.Fprintf(w, "%s = %v\n", name, values[k])
+	}
+}
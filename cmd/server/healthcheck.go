@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"web-server-go-docker/internal/probe"
+)
+
+// runHealthcheck опрашивает readiness endpoint запущенного экземпляра.
+// Используется в Docker HEALTHCHECK, поэтому образу не нужны shell и curl.
+func runHealthcheck(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	var opts probe.Options
+	fs.StringVar(&opts.URL, "url", fmt.Sprintf("http://127.0.0.1:%s/ready", port), "endpoint URL to probe")
+	fs.StringVar(&opts.SocketPath, "unix-socket", os.Getenv("SOCKET_PATH"), "connect over this Unix socket instead of TCP")
+	fs.DurationVar(&opts.Timeout, "timeout", 3*time.Second, "probe timeout")
+	fs.StringVar(&opts.CAFile, "ca-file", "", "PEM file with CA certificates for https")
+	fs.StringVar(&opts.ServerName, "server-name", "", "expected TLS server name")
+	fs.BoolVar(&opts.InsecureSkipVerify, "insecure", false, "skip TLS certificate verification")
+	quiet := fs.Bool("quiet", false, "do not print result")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	result, err := probe.Run(context.Background(), opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "healthcheck failed: %v\n", err)
+		return 1
+	}
+
+	if !*quiet {
+		fmt.Fprintf(stdout, "healthy: %s returned %s in %s\n", opts.URL, result.Status, result.Duration)
+	}
+	return 0
+}
@@ -1,28 +1,73 @@
-
 package main
 
 import (
-	"log"
-
-	"web-server-go-docker/internal/config"
-	"web-server-go-docker/internal/server"
+	"fmt"
+	"io"
+	"os"
+	"strings"
 )
 
+// command описывает подкоманду CLI
+type command struct {
+	name  string
+	usage string
+	run   func(args []string, stdout, stderr io.Writer) int
+}
+
+// commands возвращает список доступных подкоманд
+func commands() []command {
+	return []command{
+		{"serve", "Start the HTTP server (default)", runServe},
+		{"healthcheck", "Probe a running instance and exit non-zero if unhealthy", runHealthcheck},
+		{"config", "Validate or print the effective configuration (validate|print)", runConfig},
+		{"version", "Print build information", runVersion},
+		{"routes", "List registered routes with their middleware", runRoutes},
+		{"slo", "Validate SLO definitions or generate Prometheus rules (validate|rules)", runSLO},
+		{"monitoring", "Generate or check Grafana dashboards and alert rules (dashboard|alerts|check)", runMonitoring},
+		{"apikey", "Generate an API key or validate API key files (generate|validate)", runAPIKey},
+	}
+}
+
 func main() {
-	// Загружаем конфигурацию
-	cfg, err := config.Load()
-	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run выбирает подкоманду по первому аргументу.
+// Без аргументов (или если первый аргумент - флаг) выполняется serve.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && !isHelp(args[0]) {
+		return runServe(args, stdout, stderr)
+	}
+
+	if isHelp(args[0]) || args[0] == "help" {
+		printUsage(stdout)
+		return 0
 	}
 
-	// Создаем сервер
-	srv, err := server.New(cfg)
-	if err != nil {
-		log.Fatalf("Failed to create server: %v", err)
+	for _, cmd := range commands() {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout, stderr)
+		}
 	}
 
-	// Запускаем сервер
-	if err := srv.Start(); err != nil {
-		log.Fatalf("Server error: %v", err)
+	fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+	printUsage(stderr)
+	return 2
+}
+
+// isHelp проверяет, является ли аргумент запросом справки
+func isHelp(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// printUsage выводит справку по подкомандам
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: server <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-12s %s\n", cmd.name, cmd.usage)
 	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'server <command> -h' for command flags.")
 }
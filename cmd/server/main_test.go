@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRunUnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	if code := run([]string{"bogus"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "unknown command") {
+		t.Errorf("expected unknown command message, got %q", stderr.String())
+	}
+}
+
+func TestRunHealthcheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		expected int
+	}{
+		{"healthy", http.StatusOK, 0},
+		{"unhealthy", http.StatusServiceUnavailable, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer ts.Close()
+
+			var stdout, stderr bytes.Buffer
+			code := run([]string{"healthcheck", "-url", ts.URL + "/health"}, &stdout, &stderr)
+			if code != tt.expected {
+				t.Errorf("expected exit code %d, got %d (stderr: %s)", tt.expected, code, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunConfigValidate(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	if code := run([]string{"config", "validate", "-port", "3000"}, &stdout, &stderr); code != 0 {
+		t.Errorf("expected valid config, got exit code %d: %s", code, stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"config", "validate", "-port", "invalid"}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 for invalid port, got %d", code)
+	}
+}
+
+func TestRunRoutes(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	if code := run([]string{"routes"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	for _, path := range []string{"/health", "/version", "/prometheus"} {
+		if !strings.Contains(stdout.String(), path) {
+			t.Errorf("expected route %s in output:\n%s", path, stdout.String())
+		}
+	}
+}
+
+func TestRunSLORules_MatchesCommittedRules(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"slo", "rules", "-file", "../../monitoring/slo.yaml", "-job", "web-server"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	committed, err := os.ReadFile("../../monitoring/prometheus/rules/slo.yml")
+	if err != nil {
+		t.Fatalf("failed to read committed rules: %v", err)
+	}
+	if stdout.String() != string(committed) {
+		t.Error("monitoring/prometheus/rules/slo.yml is out of date, run `make slo-rules`")
+	}
+}
+
+func TestRunSLOValidate_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slo.yaml")
+	if err := os.WriteFile(path, []byte("objectives:\n  - name: api\n    route: \"*\"\n    type: uptime\n    target: 0.99\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"slo", "validate", "-file", path}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "unknown type") {
+		t.Errorf("expected unknown type error, got %q", stderr.String())
+	}
+}
+
+func TestRunMonitoringCheck(t *testing.T) {
+	t.Setenv("SLO_FILE", "../../monitoring/slo.yaml")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"monitoring", "check", "-env", "production",
+		"-rules", "../../monitoring/prometheus/rules",
+		"-dashboards", "../../monitoring/grafana/dashboards"}, &stdout, &stderr)
+	if code != 0 {
+		t.Errorf("dashboards or rules reference metrics the server does not export:\n%s", stderr.String())
+	}
+}
+
+func TestRunMonitoring_GeneratedFilesUpToDate(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process_* metrics used by the generated files are exported on Linux only")
+	}
+	t.Setenv("SLO_FILE", "../../monitoring/slo.yaml")
+
+	tests := []struct {
+		command string
+		file    string
+	}{
+		{"dashboard", "../../monitoring/grafana/dashboards/go-metrics.json"},
+		{"alerts", "../../monitoring/prometheus/rules/web-server.yml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run([]string{"monitoring", tt.command, "-env", "production"}, &stdout, &stderr); code != 0 {
+				t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+
+			committed, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stdout.String() != string(committed) {
+				t.Errorf("%s is out of date, run `make monitoring-generate`", tt.file)
+			}
+		})
+	}
+}
+
+func TestRunAPIKey(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"apikey", "generate", "-id", "billing", "-owner", "billing-team", "-scopes", "orders:read, orders:write", "-expires", "24h"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	// Сгенерированная запись проходит validate как файл ключей
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	if err := os.WriteFile(path, []byte("keys:\n"+stdout.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if code := run([]string{"apikey", "validate", "-file", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected generated key to be valid, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "1 API keys are valid") {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"apikey", "generate", "-id", "billing", "-scopes", "Orders"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 for invalid scope, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "invalid scope") {
+		t.Errorf("expected invalid scope error, got %q", stderr.String())
+	}
+}
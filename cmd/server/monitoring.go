@@ -0,0 +1,158 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"web-server-go-docker/internal/monitoring"
+	"web-server-go-docker/internal/server"
+)
+
+// runMonitoring обрабатывает подкоманды monitoring dashboard, alerts и check
+func runMonitoring(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "Usage: server monitoring <dashboard|alerts|check> [flags]")
+		return 2
+	}
+
+	sub := args[0]
+	fs := flag.NewFlagSet("monitoring "+sub, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var flags configFlags
+	flags.register(fs)
+	job := fs.String("job", "web-server", "Prometheus job label of the scraped server")
+
+	var output, title, uid, rulesDir, dashboardsDir string
+	switch sub {
+	case "dashboard":
+		fs.StringVar(&output, "o", "", "write dashboard to file instead of stdout")
+		fs.StringVar(&title, "title", "Go Web Server Monitoring", "dashboard title")
+		fs.StringVar(&uid, "uid", "go-web-server", "dashboard UID")
+	case "alerts":
+		fs.StringVar(&output, "o", "", "write rules to file instead of stdout")
+	case "check":
+		fs.StringVar(&rulesDir, "rules", "monitoring/prometheus/rules", "directory with Prometheus rule files")
+		fs.StringVar(&dashboardsDir, "dashboards", "monitoring/grafana/dashboards", "directory with Grafana dashboards")
+	default:
+		fmt.Fprintf(stderr, "unknown monitoring command %q\n", sub)
+		return 2
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	cfg, err := flags.load()
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+	if !cfg.Metrics.Enabled {
+		fmt.Fprintln(stderr, "metrics are disabled, nothing to generate")
+		return 1
+	}
+
+	srv, err := server.New(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to create server: %v\n", err)
+		return 1
+	}
+	catalog, err := exportedMetrics(srv)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to collect metrics: %v\n", err)
+		return 1
+	}
+
+	var data []byte
+	switch sub {
+	case "dashboard":
+		var routes []monitoring.Route
+		for _, r := range srv.Routes() {
+			routes = append(routes, monitoring.Route{Method: r.Method, Path: r.Path})
+		}
+		data, err = monitoring.Dashboard(catalog, monitoring.DashboardOptions{
+			Job: *job, Title: title, UID: uid, Routes: routes,
+		})
+	case "alerts":
+		data, err = monitoring.AlertsYAML(catalog, *job)
+	case "check":
+		return checkMonitoring(catalog, *job, rulesDir, dashboardsDir, stdout, stderr)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to generate %s: %v\n", sub, err)
+		return 1
+	}
+
+	if output == "" {
+		if _, err := stdout.Write(data); err != nil {
+			return 1
+		}
+		return 0
+	}
+	if err := os.WriteFile(output, data, 0o644); err != nil {
+		fmt.Fprintf(stderr, "failed to write %s: %v\n", sub, err)
+		return 1
+	}
+	return 0
+}
+
+// exportedMetrics возвращает каталог метрик сервера. Векторы появляются в registry
+// только после первого наблюдения, а uptime обновляется при scrape, поэтому сервер
+// сначала обрабатывает GET /health, а uptime обновляется напрямую: путь метрик
+// может быть закрыт авторизацией.
+func exportedMetrics(srv *server.Server) (*monitoring.Catalog, error) {
+	srv.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	srv.Metrics().UpdateUptime()
+	return monitoring.NewCatalog(srv.Metrics().Gatherer())
+}
+
+// checkMonitoring проверяет, что правила и дашборды ссылаются только на метрики сервера
+func checkMonitoring(catalog *monitoring.Catalog, job, rulesDir, dashboardsDir string, stdout, stderr io.Writer) int {
+	type source struct {
+		pattern string
+		check   func([]byte, *monitoring.Catalog, string) ([]string, error)
+	}
+	sources := []source{
+		{filepath.Join(rulesDir, "*.yml"), monitoring.CheckRules},
+		{filepath.Join(rulesDir, "*.yaml"), monitoring.CheckRules},
+		{filepath.Join(dashboardsDir, "*.json"), monitoring.CheckDashboard},
+	}
+
+	failed := false
+	for _, src := range sources {
+		files, err := filepath.Glob(src.pattern)
+		if err != nil {
+			fmt.Fprintf(stderr, "invalid pattern %s: %v\n", src.pattern, err)
+			return 2
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				fmt.Fprintf(stderr, "failed to read %s: %v\n", file, err)
+				return 1
+			}
+			problems, err := src.check(data, catalog, job)
+			if err != nil {
+				fmt.Fprintf(stderr, "%s: %v\n", file, err)
+				failed = true
+				continue
+			}
+			for _, p := range problems {
+				fmt.Fprintf(stderr, "%s: %s\n", file, p)
+				failed = true
+			}
+		}
+	}
+
+	if failed {
+		return 1
+	}
+	fmt.Fprintln(stdout, "all referenced metrics are exported")
+	return 0
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"web-server-go-docker/internal/server"
+)
+
+// runRoutes выводит зарегистрированные маршруты и их middleware
+func runRoutes(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("routes", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var flags configFlags
+	flags.register(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := flags.load()
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+
+	srv, err := server.New(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to create server: %v\n", err)
+		return 1
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tMIDDLEWARE\tDESCRIPTION")
+	for _, route := range srv.Routes() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			route.Method, route.Path, strings.Join(route.Middleware, " -> "), route.Description)
+	}
+	if err := tw.Flush(); err != nil {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"web-server-go-docker/internal/config"
+	"web-server-go-docker/internal/server"
+)
+
+// configFlags содержит флаги, переопределяющие конфигурацию из окружения
+type configFlags struct {
+	port        string
+	environment string
+	logLevel    string
+	logFormat   string
+	metricsPath string
+	metrics     string
+}
+
+// register добавляет флаги конфигурации в FlagSet
+func (f *configFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.port, "port", "", "server port (overrides PORT)")
+	fs.StringVar(&f.environment, "env", "", "environment (overrides ENVIRONMENT)")
+	fs.StringVar(&f.logLevel, "log-level", "", "log level (overrides LOG_LEVEL)")
+	fs.StringVar(&f.logFormat, "log-format", "", "log format (overrides LOG_FORMAT)")
+	fs.StringVar(&f.metricsPath, "metrics-path", "", "Prometheus metrics path (overrides METRICS_PATH)")
+	fs.StringVar(&f.metrics, "metrics", "", "enable Prometheus metrics: true|false (overrides METRICS_ENABLED)")
+}
+
+// load загружает конфигурацию из окружения и применяет флаги поверх нее
+func (f *configFlags) load() (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if f.port != "" {
+		cfg.Server.Port = f.port
+	}
+	if f.environment != "" {
+		cfg.App.Environment = f.environment
+	}
+	if f.logLevel != "" {
+		cfg.Logging.Level = f.logLevel
+	}
+	if f.logFormat != "" {
+		cfg.Logging.Format = f.logFormat
+	}
+	if f.metricsPath != "" {
+		cfg.Metrics.Path = f.metricsPath
+	}
+	switch f.metrics {
+	case "":
+	case "true":
+		cfg.Metrics.Enabled = true
+	case "false":
+		cfg.Metrics.Enabled = false
+	default:
+		return nil, fmt.Errorf("invalid -metrics value: %s", f.metrics)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// runServe запускает HTTP сервер
+func runServe(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var flags configFlags
+	flags.register(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	// Загружаем конфигурацию
+	cfg, err := flags.load()
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+
+	// Создаем сервер
+	srv, err := server.New(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to create server: %v\n", err)
+		return 1
+	}
+
+	// Запускаем сервер
+	if err := srv.Start(); err != nil {
+		fmt.Fprintf(stderr, "Server error: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"web-server-go-docker/internal/slo"
+)
+
+// runSLO обрабатывает подкоманды slo validate и slo rules
+func runSLO(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "Usage: server slo <validate|rules> [flags]")
+		return 2
+	}
+
+	sub := args[0]
+	fs := flag.NewFlagSet("slo "+sub, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	file := fs.String("file", os.Getenv("SLO_FILE"), "SLO definitions file (defaults to SLO_FILE)")
+	var job, output string
+	if sub == "rules" {
+		fs.StringVar(&job, "job", "web-server", "Prometheus job label of the scraped server")
+		fs.StringVar(&output, "o", "", "write rules to file instead of stdout")
+	}
+
+	switch sub {
+	case "validate", "rules":
+	default:
+		fmt.Fprintf(stderr, "unknown slo command %q\n", sub)
+		return 2
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *file == "" {
+		fmt.Fprintln(stderr, "SLO file is required (-file or SLO_FILE)")
+		return 2
+	}
+
+	spec, err := slo.Load(*file)
+	if err != nil {
+		fmt.Fprintf(stderr, "invalid SLO definitions: %v\n", err)
+		return 1
+	}
+
+	if sub == "validate" {
+		fmt.Fprintf(stdout, "%d SLO definitions are valid\n", len(spec.Objectives))
+		return 0
+	}
+
+	rules, err := spec.RulesYAML(job)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to generate rules: %v\n", err)
+		return 1
+	}
+
+	if output == "" {
+		if _, err := stdout.Write(rules); err != nil {
+			return 1
+		}
+		return 0
+	}
+	if err := os.WriteFile(output, rules, 0o644); err != nil {
+		fmt.Fprintf(stderr, "failed to write rules: %v\n", err)
+		return 1
+	}
+	return 0
+}
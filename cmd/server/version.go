@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"web-server-go-docker/internal/buildinfo"
+)
+
+// runVersion выводит информацию о сборке
+func runVersion(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	asJSON := fs.Bool("json", false, "print as JSON")
+	deps := fs.Bool("deps", false, "include module dependencies")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	info := buildinfo.Get()
+	if !*deps {
+		info.Dependencies = nil
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			fmt.Fprintf(stderr, "failed to encode version: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(stdout, "version:    %s\n", info.Version)
+	fmt.Fprintf(stdout, "commit:     %s\n", info.Commit)
+	fmt.Fprintf(stdout, "dirty:      %t\n", info.Dirty)
+	if info.BuildTime != "" {
+		fmt.Fprintf(stdout, "built:      %s\n", info.BuildTime)
+	}
+	if info.CommitTime != "" {
+		fmt.Fprintf(stdout, "committed:  %s\n", info.CommitTime)
+	}
+	fmt.Fprintf(stdout, "go:         %s\n", info.GoVersion)
+	fmt.Fprintf(stdout, "module:     %s\n", info.Module)
+
+	for _, d := range info.Dependencies {
+		if d.Replace != "" {
+			fmt.Fprintf(stdout, "dep:        %s %s => %s\n", d.Path, d.Version, d.Replace)
+			continue
+		}
+		fmt.Fprintf(stdout, "dep:        %s %s\n", d.Path, d.Version)
+	}
+
+	return 0
+}
@@ -0,0 +1,215 @@
+// Package apikey описывает API ключи сервисов: хранит их SHA-256 хеши с владельцем,
+// scopes и сроком действия, загружает из YAML файла или каталога и проверяет
+// ключи запросов сравнением за постоянное время.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Header - HTTP заголовок с ключом; также принимается Authorization: Bearer <key>
+const Header = "X-API-Key"
+
+// AllScopes в списке scopes ключа дает доступ к любому маршруту
+const AllScopes = "*"
+
+// hashPrefix - алгоритм в поле hash. Ключи случайные и длинные, поэтому быстрый
+// SHA-256 достаточен, а bcrypt замедлил бы каждый запрос.
+const hashPrefix = "sha256:"
+
+var (
+	idPattern    = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+	scopePattern = regexp.MustCompile(`^(\*|[a-z][a-z0-9_.:-]*)$`)
+	hashPattern  = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+)
+
+// Key - описание API ключа без самого ключа
+type Key struct {
+	// ID - уникальный идентификатор, попадает в логи
+	ID string `yaml:"id"`
+	// Owner - владелец ключа (сервис или команда)
+	Owner string `yaml:"owner,omitempty"`
+	// Hash - "sha256:<hex>" от ключа, см. Hash
+	Hash string `yaml:"hash"`
+	// Scopes - разрешения ключа; AllScopes разрешает все
+	Scopes []string `yaml:"scopes,omitempty"`
+	// ExpiresAt - момент, после которого ключ не принимается; нулевое значение - бессрочный
+	ExpiresAt time.Time `yaml:"expires_at,omitempty"`
+}
+
+// File - содержимое файла ключей
+type File struct {
+	Keys []Key `yaml:"keys"`
+}
+
+// Expired сообщает, истек ли срок действия ключа к моменту now
+func (k *Key) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+// HasScopes сообщает, есть ли у ключа все scopes
+func (k *Key) HasScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		if !k.hasScope(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func (k *Key) hasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == AllScopes {
+			return true
+		}
+	}
+	return false
+}
+
+// Hash возвращает значение поля hash для ключа
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+// Generate возвращает новый случайный ключ (32 байта в base64url)
+func Generate() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
+
+// Load читает ключи из YAML файла или из всех *.yaml и *.yml файлов каталога.
+// Каталог удобен для ротации: новый ключ добавляется отдельным файлом.
+func Load(path string) ([]Key, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+	}
+
+	var keys []Key
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API keys: %w", err)
+		}
+		parsed, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		keys = append(keys, parsed...)
+	}
+	if err := Validate(keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Parse разбирает и проверяет файл ключей в YAML
+func Parse(data []byte) ([]Key, error) {
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid API keys file: %w", err)
+	}
+	if err := Validate(f.Keys); err != nil {
+		return nil, err
+	}
+	return f.Keys, nil
+}
+
+// Validate проверяет описания ключей: формат полей и уникальность ID и хешей
+func Validate(keys []Key) error {
+	ids := make(map[string]bool)
+	hashes := make(map[string]string)
+	for _, k := range keys {
+		if !idPattern.MatchString(k.ID) {
+			return fmt.Errorf("invalid API key id %q", k.ID)
+		}
+		if ids[k.ID] {
+			return fmt.Errorf("duplicate API key id %q", k.ID)
+		}
+		ids[k.ID] = true
+
+		if !hashPattern.MatchString(k.Hash) {
+			return fmt.Errorf("API key %s: hash must be \"sha256:\" and 64 lowercase hex digits", k.ID)
+		}
+		if other, ok := hashes[k.Hash]; ok {
+			return fmt.Errorf("API keys %s and %s have the same hash", other, k.ID)
+		}
+		hashes[k.Hash] = k.ID
+
+		for _, scope := range k.Scopes {
+			if !scopePattern.MatchString(scope) {
+				return fmt.Errorf("API key %s: invalid scope %q", k.ID, scope)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidScope проверяет имя scope, требуемого маршрутом
+func ValidScope(scope string) bool {
+	return scope != AllScopes && scopePattern.MatchString(scope)
+}
+
+type contextKey struct{}
+
+// holder позволяет внешнему middleware (логированию) узнать ключ, которым
+// аутентифицирован запрос во внутреннем middleware маршрута
+type holder struct {
+	key *Key
+}
+
+type holderKey struct{}
+
+// Track подготавливает контекст запроса, чтобы FromContext во внешнем middleware
+// вернул ключ, установленный позже через WithContext
+func Track(ctx context.Context) context.Context {
+	return context.WithValue(ctx, holderKey{}, &holder{})
+}
+
+// WithContext возвращает контекст с ключом, которым аутентифицирован запрос
+func WithContext(ctx context.Context, k *Key) context.Context {
+	if h, ok := ctx.Value(holderKey{}).(*holder); ok {
+		h.key = k
+	}
+	return context.WithValue(ctx, contextKey{}, k)
+}
+
+// FromContext возвращает ключ запроса или nil, если запрос без ключа
+func FromContext(ctx context.Context) *Key {
+	if k, ok := ctx.Value(contextKey{}).(*Key); ok {
+		return k
+	}
+	if h, ok := ctx.Value(holderKey{}).(*holder); ok {
+		return h.key
+	}
+	return nil
+}
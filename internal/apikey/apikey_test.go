@@ -0,0 +1,208 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// entryYAML возвращает запись файла ключей для ключа raw
+func entryYAML(id, raw string, extra ...string) string {
+	lines := []string{"  - id: " + id, "    hash: " + Hash(raw)}
+	for _, e := range extra {
+		lines = append(lines, "    "+e)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"valid", "keys:\n" + entryYAML("ci", "a", "owner: platform", "scopes: [deploy:write, '*']", "expires_at: 2030-01-01T00:00:00Z"), ""},
+		{"empty", "keys: []\n", ""},
+		{"invalid id", "keys:\n" + entryYAML("ci key", "a"), "invalid API key id"},
+		{"duplicate id", "keys:\n" + entryYAML("ci", "a") + entryYAML("ci", "b"), "duplicate API key id"},
+		{"duplicate hash", "keys:\n" + entryYAML("ci", "a") + entryYAML("cd", "a"), "same hash"},
+		{"plain key instead of hash", "keys:\n  - id: ci\n    hash: secret\n", "hash must be"},
+		{"invalid scope", "keys:\n" + entryYAML("ci", "a", "scopes: [Deploy Write]"), "invalid scope"},
+		{"invalid expiry", "keys:\n" + entryYAML("ci", "a", "expires_at: tomorrow"), "invalid API keys file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoad_Directory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.yaml"), "keys:\n"+entryYAML("ci", "a"))
+	writeFile(t, filepath.Join(dir, "b.yml"), "keys:\n"+entryYAML("cd", "b"))
+	writeFile(t, filepath.Join(dir, "README.md"), "not a key file")
+
+	keys, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].ID != "ci" || keys[1].ID != "cd" {
+		t.Errorf("unexpected keys: %+v", keys)
+	}
+
+	// ID уникальны во всем каталоге
+	writeFile(t, filepath.Join(dir, "c.yaml"), "keys:\n"+entryYAML("ci", "c"))
+	if _, err := Load(dir); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("expected duplicate id error across files, got %v", err)
+	}
+}
+
+func TestStore_Authenticate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	writeFile(t, path, "keys:\n"+
+		entryYAML("ci", "ci-key", "scopes: [deploy:write]")+
+		entryYAML("old", "old-key", "expires_at: 2020-01-01T00:00:00Z"))
+
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		raw     string
+		wantID  string
+		wantErr error
+	}{
+		{"valid key", "ci-key", "ci", nil},
+		{"unknown key", "guess", "", ErrInvalidKey},
+		{"expired key", "old-key", "old", ErrExpiredKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := store.Authenticate(tt.raw)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantID != "" && (key == nil || key.ID != tt.wantID) {
+				t.Errorf("expected key %s, got %+v", tt.wantID, key)
+			}
+		})
+	}
+
+	// Ротация: новый ключ принимается после Reload, старый - нет
+	writeFile(t, path, "keys:\n"+entryYAML("ci", "ci-key-2"))
+	if err := store.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Authenticate("ci-key"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected rotated key to be rejected, got %v", err)
+	}
+	if _, err := store.Authenticate("ci-key-2"); err != nil {
+		t.Errorf("expected new key to be accepted, got %v", err)
+	}
+
+	// Ошибка в файле не сбрасывает загруженные ключи
+	writeFile(t, path, "keys:\n  - id: ci\n    hash: broken\n")
+	if err := store.Reload(); err == nil {
+		t.Error("expected reload error")
+	}
+	if _, err := store.Authenticate("ci-key-2"); err != nil || store.Len() != 1 {
+		t.Errorf("expected previous keys after failed reload, got %v (%d keys)", err, store.Len())
+	}
+}
+
+func TestNewStore_Empty(t *testing.T) {
+	store, err := NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Authenticate(""); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected empty store to reject keys, got %v", err)
+	}
+
+	if _, err := NewStore(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestKey_HasScopes(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		key     Key
+		scopes  []string
+		want    bool
+		expired bool
+	}{
+		{"no scopes required", Key{}, nil, true, false},
+		{"all scopes present", Key{Scopes: []string{"a", "b"}}, []string{"b", "a"}, true, false},
+		{"missing scope", Key{Scopes: []string{"a"}}, []string{"a", "b"}, false, false},
+		{"wildcard", Key{Scopes: []string{AllScopes}}, []string{"a", "b"}, true, false},
+		{"expires later", Key{ExpiresAt: now.Add(time.Second)}, nil, true, false},
+		{"expires now", Key{ExpiresAt: now}, nil, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.key.HasScopes(tt.scopes...); got != tt.want {
+				t.Errorf("HasScopes(%v) = %v, want %v", tt.scopes, got, tt.want)
+			}
+			if got := tt.key.Expired(now); got != tt.expired {
+				t.Errorf("Expired() = %v, want %v", got, tt.expired)
+			}
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	if FromContext(context.Background()) != nil {
+		t.Error("expected no key in empty context")
+	}
+
+	// Внешний middleware видит ключ, установленный во внутреннем
+	outer := Track(context.Background())
+	key := &Key{ID: "ci"}
+	inner := WithContext(outer, key)
+	if FromContext(inner) != key || FromContext(outer) != key {
+		t.Error("expected key in inner and tracked outer context")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	a, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := Generate()
+	if len(a) != 43 || a == b {
+		t.Errorf("unexpected keys %q and %q", a, b)
+	}
+	if !hashPattern.MatchString(Hash(a)) {
+		t.Errorf("unexpected hash %q", Hash(a))
+	}
+}
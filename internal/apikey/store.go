@@ -0,0 +1,88 @@
+package apikey
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Ошибки Authenticate
+var (
+	ErrInvalidKey = errors.New("invalid API key")
+	ErrExpiredKey = errors.New("API key expired")
+)
+
+// entry - ключ с разобранным хешем
+type entry struct {
+	key    Key
+	digest [sha256.Size]byte
+}
+
+// Store хранит загруженные ключи. Reload заменяет набор целиком, поэтому
+// запросы видят либо старые, либо новые ключи. Методы безопасны для конкурентного вызова.
+type Store struct {
+	path    string
+	now     func() time.Time
+	entries atomic.Pointer[[]entry]
+}
+
+// NewStore загружает ключи из файла или каталога path. Пустой path дает
+// хранилище без ключей: маршруты, требующие ключ, отклоняют все запросы.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, now: time.Now}
+	s.entries.Store(&[]entry{})
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload перечитывает ключи. При ошибке остаются прежние ключи.
+func (s *Store) Reload() error {
+	if s.path == "" {
+		return nil
+	}
+	keys, err := Load(s.path)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]entry, len(keys))
+	for i, k := range keys {
+		entries[i].key = k
+		// Формат hash проверен в Validate
+		hex.Decode(entries[i].digest[:], []byte(k.Hash[len(hashPrefix):]))
+	}
+	s.entries.Store(&entries)
+	return nil
+}
+
+// Len возвращает число загруженных ключей
+func (s *Store) Len() int {
+	return len(*s.entries.Load())
+}
+
+// Authenticate возвращает описание ключа. Хеш сравнивается со всеми ключами
+// за постоянное время, без раннего выхода. Для истекшего ключа возвращается
+// описание и ErrExpiredKey, чтобы его можно было залогировать.
+func (s *Store) Authenticate(key string) (*Key, error) {
+	sum := sha256.Sum256([]byte(key))
+	entries := *s.entries.Load()
+
+	var found *Key
+	for i := range entries {
+		if subtle.ConstantTimeCompare(sum[:], entries[i].digest[:]) == 1 {
+			found = &entries[i].key
+		}
+	}
+	if found == nil {
+		return nil, ErrInvalidKey
+	}
+	if found.Expired(s.now()) {
+		return found, ErrExpiredKey
+	}
+	return found, nil
+}
@@ -0,0 +1,115 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync"
+)
+
+// Значения, внедряемые при сборке через -ldflags "-X ...".
+// Пустое значение означает, что используется информация из runtime/debug.
+var (
+	version   string
+	commit    string
+	buildTime string
+	dirty     string
+)
+
+// DefaultVersion используется, если версия не задана ни через ldflags, ни модулем
+const DefaultVersion = "dev"
+
+// Dependency описывает зависимость, вошедшую в бинарник
+type Dependency struct {
+	Path    string
+	Version string
+	Sum     string
+	Replace string
+}
+
+// Info содержит сведения о сборке текущего бинарника
+type Info struct {
+	Version      string
+	Commit       string
+	Dirty        bool
+	BuildTime    string
+	CommitTime   string
+	GoVersion    string
+	Module       string
+	Dependencies []Dependency
+}
+
+// ShortCommit возвращает сокращенный хеш коммита
+func (i Info) ShortCommit() string {
+	if len(i.Commit) > 12 {
+		return i.Commit[:12]
+	}
+	return i.Commit
+}
+
+var (
+	once   sync.Once
+	cached Info
+)
+
+// Get возвращает информацию о сборке. Результат вычисляется один раз.
+func Get() Info {
+	once.Do(func() {
+		bi, _ := debug.ReadBuildInfo()
+		cached = resolve(bi)
+	})
+	return cached
+}
+
+// resolve объединяет значения из ldflags и встроенные VCS метаданные.
+// Значения из ldflags имеют приоритет.
+func resolve(bi *debug.BuildInfo) Info {
+	info := Info{
+		Version:   version,
+		Commit:    commit,
+		BuildTime: buildTime,
+		GoVersion: runtime.Version(),
+	}
+	if d, err := strconv.ParseBool(dirty); err == nil {
+		info.Dirty = d
+	}
+
+	if bi != nil {
+		info.Module = bi.Main.Path
+		if bi.GoVersion != "" {
+			info.GoVersion = bi.GoVersion
+		}
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				info.CommitTime = s.Value
+			case "vcs.modified":
+				if dirty == "" {
+					info.Dirty = s.Value == "true"
+				}
+			}
+		}
+
+		for _, dep := range bi.Deps {
+			d := Dependency{Path: dep.Path, Version: dep.Version, Sum: dep.Sum}
+			if dep.Replace != nil {
+				d.Replace = dep.Replace.Path + "@" + dep.Replace.Version
+			}
+			info.Dependencies = append(info.Dependencies, d)
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = DefaultVersion
+	}
+
+	return info
+}
@@ -0,0 +1,80 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.22.0",
+		Main:      debug.Module{Path: "web-server-go-docker", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "github.com/prometheus/client_golang", Version: "v1.17.0"},
+			{
+				Path:    "example.com/old",
+				Version: "v1.0.0",
+				Replace: &debug.Module{Path: "example.com/new", Version: "v1.1.0"},
+			},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+			{Key: "vcs.time", Value: "2025-01-01T00:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	info := resolve(bi)
+
+	if info.Version != DefaultVersion {
+		t.Errorf("expected version %q for devel build, got %q", DefaultVersion, info.Version)
+	}
+	if info.Commit != "0123456789abcdef0123" {
+		t.Errorf("expected commit from vcs.revision, got %q", info.Commit)
+	}
+	if info.ShortCommit() != "0123456789ab" {
+		t.Errorf("unexpected short commit %q", info.ShortCommit())
+	}
+	if !info.Dirty {
+		t.Error("expected dirty flag from vcs.modified")
+	}
+	if info.CommitTime != "2025-01-01T00:00:00Z" {
+		t.Errorf("unexpected commit time %q", info.CommitTime)
+	}
+	if info.GoVersion != "go1.22.0" {
+		t.Errorf("unexpected go version %q", info.GoVersion)
+	}
+	if len(info.Dependencies) != 2 {
+		t.Fatalf("expected 2 dependencies, got %d", len(info.Dependencies))
+	}
+	if info.Dependencies[1].Replace != "example.com/new@v1.1.0" {
+		t.Errorf("unexpected replace %q", info.Dependencies[1].Replace)
+	}
+}
+
+func TestResolveLdflagsOverride(t *testing.T) {
+	origVersion, origCommit, origDirty := version, commit, dirty
+	defer func() { version, commit, dirty = origVersion, origCommit, origDirty }()
+
+	version, commit, dirty = "2.3.4", "feedface", "false"
+
+	bi := &debug.BuildInfo{
+		Main: debug.Module{Path: "web-server-go-docker", Version: "v0.0.1"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "deadbeef"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	info := resolve(bi)
+
+	if info.Version != "2.3.4" {
+		t.Errorf("expected ldflags version, got %q", info.Version)
+	}
+	if info.Commit != "feedface" {
+		t.Errorf("expected ldflags commit, got %q", info.Commit)
+	}
+	if info.Dirty {
+		t.Error("expected ldflags dirty flag to take precedence")
+	}
+}
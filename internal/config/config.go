@@ -3,43 +3,161 @@ package config
 
 import (
 	"fmt"
+	"math"
+	"net/netip"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
+
+	"web-server-go-docker/internal/buildinfo"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Config представляет конфигурацию приложения
 type Config struct {
-	Server   ServerConfig
-	App      AppConfig
-	Metrics  MetricsConfig
-	Logging  LoggingConfig
+	Server    ServerConfig    `json:"server"`
+	App       AppConfig       `json:"app"`
+	Metrics   MetricsConfig   `json:"metrics"`
+	Logging   LoggingConfig   `json:"logging"`
+	OpenAPI   OpenAPIConfig   `json:"openapi"`
+	Admin     AdminConfig     `json:"admin"`
+	Dashboard DashboardConfig `json:"dashboard"`
+	SLO       SLOConfig       `json:"slo"`
+	APIKeys   APIKeysConfig   `json:"api_keys"`
 }
 
 // ServerConfig содержит настройки HTTP сервера
 type ServerConfig struct {
-	Port         string
-	ReadTimeout  time.Duration
-	WriteTimeout time.Duration
-	IdleTimeout  time.Duration
+	Port         string        `json:"port"`
+	ReadTimeout  time.Duration `json:"read_timeout"`
+	WriteTimeout time.Duration `json:"write_timeout"`
+	IdleTimeout  time.Duration `json:"idle_timeout"`
+	SocketPath   string        `json:"socket_path"`
 }
 
 // AppConfig содержит настройки приложения
 type AppConfig struct {
-	Environment string
-	Version     string
+	Environment string `json:"environment"`
+	Version     string `json:"version"`
 }
 
 // MetricsConfig содержит настройки метрик
 type MetricsConfig struct {
-	Enabled bool
-	Path    string
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"`
+
+	// История статистики для /metrics/history: одна точка на HistoryResolution,
+	// хранится HistoryRetention
+	HistoryResolution time.Duration `json:"history_resolution"`
+	HistoryRetention  time.Duration `json:"history_retention"`
+
+	// Поток метрик /metrics/stream (Server-Sent Events)
+	StreamInterval       time.Duration `json:"stream_interval"`
+	StreamMaxSubscribers int           `json:"stream_max_subscribers"`
+
+	// Границы бакетов гистограмм по имени метрики; для остальных используются значения
+	// по умолчанию из пакета metrics
+	Buckets map[string][]float64 `json:"buckets"`
+
+	// Native (sparse) гистограммы Prometheus, отдаются в protobuf формате
+	NativeHistograms            bool    `json:"native_histograms"`
+	NativeHistogramBucketFactor float64 `json:"native_histogram_bucket_factor"`
+	NativeHistogramMaxBuckets   int     `json:"native_histogram_max_buckets"`
+
+	// Перцентили задержки из скользящих окон как gauge без меток маршрутов
+	Quantiles bool `json:"quantiles"`
+
+	// Дублирование метрик в StatsD/DogStatsD агент; пустой адрес отключает отправку
+	StatsDAddress       string        `json:"statsd_address"`
+	StatsDFlavor        string        `json:"statsd_flavor"`
+	StatsDPrefix        string        `json:"statsd_prefix"`
+	StatsDTags          []string      `json:"statsd_tags"`
+	StatsDFlushInterval time.Duration `json:"statsd_flush_interval"`
+
+	// Отправка метрик по OTLP/HTTP (JSON); пустой endpoint отключает отправку
+	OTLPEndpoint    string            `json:"otlp_endpoint" secret:"userinfo"`
+	OTLPHeaders     map[string]string `json:"otlp_headers" secret:"true"`
+	OTLPInterval    time.Duration     `json:"otlp_interval"`
+	OTLPTemporality string            `json:"otlp_temporality"`
+	OTLPServiceName string            `json:"otlp_service_name"`
+
+	// Отправка в Pushgateway: периодически и при остановке; пустой URL отключает отправку.
+	// Группа: job, environment, instance и PushgatewayGrouping.
+	PushgatewayURL      string            `json:"pushgateway_url" secret:"userinfo"`
+	PushgatewayJob      string            `json:"pushgateway_job"`
+	PushgatewayInstance string            `json:"pushgateway_instance"`
+	PushgatewayGrouping map[string]string `json:"pushgateway_grouping"`
+	PushgatewayInterval time.Duration     `json:"pushgateway_interval"`
+	PushgatewayRetries  int               `json:"pushgateway_retries"`
+
+	// Префикс пользовательских метрик сервисов (Metrics.NewCounter и др.)
+	Namespace string `json:"namespace"`
+	// Предел комбинаций меток одной метрики, встроенной или пользовательской;
+	// сверх него значения меток записываются как "other"
+	MaxSeries int `json:"max_series"`
+
+	// Доступ к пути метрик (scrape): basic auth (логин=bcrypt хеш), bearer token
+	// и список адресов и подсетей; пустые значения отключают проверку
+	ScrapeUsers           map[string]string `json:"scrape_users" secret:"true"`
+	ScrapeBearerToken     string            `json:"scrape_bearer_token" secret:"true"`
+	ScrapeAllowedNetworks []string          `json:"scrape_allowed_networks"`
 }
 
 // LoggingConfig содержит настройки логирования
 type LoggingConfig struct {
-	Level  string
-	Format string
+	Level  string `json:"level"`
+	Format string `json:"format"`
+}
+
+// OpenAPIConfig содержит настройки документации API
+type OpenAPIConfig struct {
+	Enabled   bool `json:"enabled"`
+	UIEnabled bool `json:"ui_enabled"`
+	// UIScriptURL и UIScriptIntegrity - бандл Redoc страницы /docs и его SRI хеш;
+	// без URL используется зафиксированная версия на CDN
+	UIScriptURL       string `json:"ui_script_url,omitempty"`
+	UIScriptIntegrity string `json:"ui_script_integrity,omitempty"`
+
+	// Проверка запросов и ответов по описанию маршрута (WithOperation)
+	ValidateRequests  bool `json:"validate_requests"`
+	ValidateResponses bool `json:"validate_responses"`
+}
+
+// metricNamespacePattern - допустимый префикс имен метрик
+var metricNamespacePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// integrityPattern - значение атрибута integrity (Subresource Integrity)
+var integrityPattern = regexp.MustCompile(`^sha(256|384|512)-[A-Za-z0-9+/]+={0,2}$`)
+
+// minScrapeTokenLength - минимальная длина bearer token для пути метрик
+const minScrapeTokenLength = 16
+
+// maxHistoryPoints ограничивает размер буфера истории метрик (неделя при 10s)
+const maxHistoryPoints = 60480
+
+// AdminConfig содержит учетные данные для административных страниц (basic auth)
+type AdminConfig struct {
+	Username string `json:"username"`
+	Password string `json:"password" secret:"true"`
+}
+
+// DashboardConfig содержит настройки встроенной страницы состояния
+type DashboardConfig struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"`
+}
+
+// SLOConfig содержит путь к файлу с описанием SLO маршрутов (YAML, пакет slo)
+type SLOConfig struct {
+	File string `json:"file"`
+}
+
+// APIKeysConfig содержит путь к файлу или каталогу с хешами API ключей (YAML, пакет apikey)
+type APIKeysConfig struct {
+	File string `json:"file"`
 }
 
 // Load загружает конфигурацию из переменных окружения с валидацией
@@ -50,30 +168,101 @@ func Load() (*Config, error) {
 			ReadTimeout:  getDurationEnv("READ_TIMEOUT", 15*time.Second),
 			WriteTimeout: getDurationEnv("WRITE_TIMEOUT", 15*time.Second),
 			IdleTimeout:  getDurationEnv("IDLE_TIMEOUT", 60*time.Second),
+			SocketPath:   getEnv("SOCKET_PATH", ""),
 		},
 		App: AppConfig{
 			Environment: getEnv("ENVIRONMENT", "development"),
-			Version:     getEnv("APP_VERSION", "1.0.0"),
+			Version:     getEnv("APP_VERSION", buildinfo.Get().Version),
 		},
 		Metrics: MetricsConfig{
 			Enabled: getBoolEnv("METRICS_ENABLED", true),
 			Path:    getEnv("METRICS_PATH", "/prometheus"),
+
+			HistoryResolution: getDurationEnv("METRICS_HISTORY_RESOLUTION", 10*time.Second),
+			HistoryRetention:  getDurationEnv("METRICS_HISTORY_RETENTION", time.Hour),
+
+			StreamInterval:       getDurationEnv("METRICS_STREAM_INTERVAL", 5*time.Second),
+			StreamMaxSubscribers: getIntEnv("METRICS_STREAM_MAX_SUBSCRIBERS", 100),
+
+			NativeHistograms:            getBoolEnv("METRICS_NATIVE_HISTOGRAMS", false),
+			NativeHistogramBucketFactor: getFloatEnv("METRICS_NATIVE_HISTOGRAM_BUCKET_FACTOR", 1.1),
+			NativeHistogramMaxBuckets:   getIntEnv("METRICS_NATIVE_HISTOGRAM_MAX_BUCKETS", 160),
+
+			Quantiles: getBoolEnv("METRICS_QUANTILES", false),
+
+			StatsDAddress:       getEnv("METRICS_STATSD_ADDRESS", ""),
+			StatsDFlavor:        getEnv("METRICS_STATSD_FLAVOR", "dogstatsd"),
+			StatsDPrefix:        getEnv("METRICS_STATSD_PREFIX", "webserver."),
+			StatsDTags:          getListEnv("METRICS_STATSD_TAGS"),
+			StatsDFlushInterval: getDurationEnv("METRICS_STATSD_FLUSH_INTERVAL", time.Second),
+
+			OTLPEndpoint:    getEnv("METRICS_OTLP_ENDPOINT", ""),
+			OTLPHeaders:     getMapEnv("METRICS_OTLP_HEADERS"),
+			OTLPInterval:    getDurationEnv("METRICS_OTLP_INTERVAL", time.Minute),
+			OTLPTemporality: getEnv("METRICS_OTLP_TEMPORALITY", "cumulative"),
+			OTLPServiceName: getEnv("METRICS_OTLP_SERVICE_NAME", "web-server"),
+
+			PushgatewayURL:      getEnv("METRICS_PUSHGATEWAY_URL", ""),
+			PushgatewayJob:      getEnv("METRICS_PUSHGATEWAY_JOB", "web-server"),
+			PushgatewayInstance: getEnv("METRICS_PUSHGATEWAY_INSTANCE", hostname()),
+			PushgatewayGrouping: getMapEnv("METRICS_PUSHGATEWAY_GROUPING"),
+			PushgatewayInterval: getDurationEnv("METRICS_PUSHGATEWAY_INTERVAL", 30*time.Second),
+			PushgatewayRetries:  getIntEnv("METRICS_PUSHGATEWAY_RETRIES", 3),
+
+			Namespace: getEnv("METRICS_NAMESPACE", "app"),
+			// METRICS_CUSTOM_MAX_SERIES - прежнее имя, когда предел действовал только
+			// для пользовательских метрик; поддерживается для совместимости
+			MaxSeries: getIntEnv("METRICS_MAX_SERIES", getIntEnv("METRICS_CUSTOM_MAX_SERIES", 1000)),
+
+			ScrapeUsers:           getMapEnv("METRICS_SCRAPE_USERS"),
+			ScrapeBearerToken:     getEnv("METRICS_SCRAPE_BEARER_TOKEN", ""),
+			ScrapeAllowedNetworks: getListEnv("METRICS_SCRAPE_ALLOWED_NETWORKS"),
 		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
 		},
+		OpenAPI: OpenAPIConfig{
+			Enabled:   getBoolEnv("OPENAPI_ENABLED", true),
+			UIEnabled: getBoolEnv("OPENAPI_UI_ENABLED", false),
+
+			UIScriptURL:       getEnv("OPENAPI_UI_SCRIPT_URL", ""),
+			UIScriptIntegrity: getEnv("OPENAPI_UI_SCRIPT_INTEGRITY", ""),
+
+			ValidateRequests:  getBoolEnv("OPENAPI_VALIDATE_REQUESTS", false),
+			ValidateResponses: getBoolEnv("OPENAPI_VALIDATE_RESPONSES", false),
+		},
+		Admin: AdminConfig{
+			Username: getEnv("ADMIN_USERNAME", "admin"),
+			Password: getEnv("ADMIN_PASSWORD", ""),
+		},
+		Dashboard: DashboardConfig{
+			Enabled: getBoolEnv("DASHBOARD_ENABLED", false),
+			Path:    getEnv("DASHBOARD_PATH", "/dashboard"),
+		},
+		SLO: SLOConfig{
+			File: getEnv("SLO_FILE", ""),
+		},
+		APIKeys: APIKeysConfig{
+			File: getEnv("API_KEYS_FILE", ""),
+		},
+	}
+
+	buckets, err := parseBuckets(getEnv("METRICS_BUCKETS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid METRICS_BUCKETS: %w", err)
 	}
+	config.Metrics.Buckets = buckets
 
-	if err := config.validate(); err != nil {
+	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 
 	return config, nil
 }
 
-// validate проверяет корректность конфигурации
-func (c *Config) validate() error {
+// Validate проверяет корректность конфигурации
+func (c *Config) Validate() error {
 	// Валидация порта
 	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
 		return fmt.Errorf("invalid port: %s", c.Server.Port)
@@ -102,6 +291,127 @@ func (c *Config) validate() error {
 		return fmt.Errorf("invalid log level: %s", c.Logging.Level)
 	}
 
+	// Валидация пути метрик: он регистрируется в router как обычный маршрут
+	if c.Metrics.Enabled && !strings.HasPrefix(c.Metrics.Path, "/") {
+		return fmt.Errorf("invalid metrics path: %s", c.Metrics.Path)
+	}
+
+	// Валидация истории метрик: буфер выделяется целиком при старте
+	if c.Metrics.HistoryResolution < time.Second {
+		return fmt.Errorf("invalid metrics history resolution: %s (minimum 1s)", c.Metrics.HistoryResolution)
+	}
+	if c.Metrics.HistoryRetention < c.Metrics.HistoryResolution {
+		return fmt.Errorf("metrics history retention %s is shorter than resolution %s",
+			c.Metrics.HistoryRetention, c.Metrics.HistoryResolution)
+	}
+	if points := c.Metrics.HistoryRetention / c.Metrics.HistoryResolution; points > maxHistoryPoints {
+		return fmt.Errorf("metrics history too large: %d points (maximum %d)", points, maxHistoryPoints)
+	}
+
+	// Валидация потока метрик: интервал ограничен, чтобы клиенты не нагружали сервер
+	if c.Metrics.StreamInterval < time.Second || c.Metrics.StreamInterval > time.Minute {
+		return fmt.Errorf("invalid metrics stream interval: %s (must be between 1s and 1m)", c.Metrics.StreamInterval)
+	}
+	if c.Metrics.StreamMaxSubscribers < 1 {
+		return fmt.Errorf("invalid metrics stream max subscribers: %d", c.Metrics.StreamMaxSubscribers)
+	}
+
+	// Неверные бакеты приводят к панике при регистрации гистограммы
+	for name, buckets := range c.Metrics.Buckets {
+		if len(buckets) == 0 {
+			return fmt.Errorf("metric %s: no histogram buckets", name)
+		}
+		for i, b := range buckets {
+			if math.IsNaN(b) || math.IsInf(b, 0) {
+				return fmt.Errorf("metric %s: invalid histogram bucket %v", name, b)
+			}
+			if i > 0 && b <= buckets[i-1] {
+				return fmt.Errorf("metric %s: histogram buckets must be in increasing order", name)
+			}
+		}
+	}
+	if c.Metrics.NativeHistograms {
+		if c.Metrics.NativeHistogramBucketFactor <= 1 {
+			return fmt.Errorf("invalid native histogram bucket factor: %v (must be greater than 1)",
+				c.Metrics.NativeHistogramBucketFactor)
+		}
+		if c.Metrics.NativeHistogramMaxBuckets < 1 {
+			return fmt.Errorf("invalid native histogram max buckets: %d", c.Metrics.NativeHistogramMaxBuckets)
+		}
+	}
+
+	// StatsD: адрес проверяется при подключении в metrics.NewStatsD
+	if c.Metrics.StatsDAddress != "" {
+		if c.Metrics.StatsDFlavor != "statsd" && c.Metrics.StatsDFlavor != "dogstatsd" {
+			return fmt.Errorf("invalid statsd flavor: %s (must be statsd or dogstatsd)", c.Metrics.StatsDFlavor)
+		}
+		if c.Metrics.StatsDFlushInterval < 100*time.Millisecond {
+			return fmt.Errorf("invalid statsd flush interval: %s (minimum 100ms)", c.Metrics.StatsDFlushInterval)
+		}
+	}
+
+	// OTLP: endpoint проверяется в metrics.NewOTLPExporter
+	if c.Metrics.OTLPEndpoint != "" {
+		if c.Metrics.OTLPTemporality != "cumulative" && c.Metrics.OTLPTemporality != "delta" {
+			return fmt.Errorf("invalid OTLP temporality: %s (must be cumulative or delta)", c.Metrics.OTLPTemporality)
+		}
+		if c.Metrics.OTLPInterval < time.Second {
+			return fmt.Errorf("invalid OTLP interval: %s (minimum 1s)", c.Metrics.OTLPInterval)
+		}
+	}
+
+	// Pushgateway: интервал 0 означает только отправку при остановке
+	if c.Metrics.PushgatewayURL != "" {
+		if c.Metrics.PushgatewayJob == "" {
+			return fmt.Errorf("pushgateway job name is required")
+		}
+		if c.Metrics.PushgatewayInterval != 0 && c.Metrics.PushgatewayInterval < time.Second {
+			return fmt.Errorf("invalid pushgateway interval: %s (0 or at least 1s)", c.Metrics.PushgatewayInterval)
+		}
+		if c.Metrics.PushgatewayRetries < 0 || c.Metrics.PushgatewayRetries > 10 {
+			return fmt.Errorf("invalid pushgateway retries: %d (must be between 0 and 10)", c.Metrics.PushgatewayRetries)
+		}
+	}
+
+	// Префикс имен пользовательских метрик: пустой или имя метрики Prometheus без ':'
+	if c.Metrics.Namespace != "" && !metricNamespacePattern.MatchString(c.Metrics.Namespace) {
+		return fmt.Errorf("invalid metrics namespace: %s", c.Metrics.Namespace)
+	}
+	if c.Metrics.MaxSeries < 1 {
+		return fmt.Errorf("invalid metrics series limit: %d (must be at least 1)", c.Metrics.MaxSeries)
+	}
+
+	// Доступ к пути метрик: хеши и адреса разбираются заранее, чтобы ошибка
+	// обнаружилась при запуске, а не при первом scrape
+	for user, hash := range c.Metrics.ScrapeUsers {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return fmt.Errorf("invalid bcrypt hash for metrics scrape user %s", user)
+		}
+	}
+	if token := c.Metrics.ScrapeBearerToken; token != "" && len(token) < minScrapeTokenLength {
+		return fmt.Errorf("metrics scrape bearer token is too short (minimum %d characters)", minScrapeTokenLength)
+	}
+	for _, network := range c.Metrics.ScrapeAllowedNetworks {
+		if !validNetwork(network) {
+			return fmt.Errorf("invalid metrics scrape network: %s", network)
+		}
+	}
+
+	// Страница состояния отдается только под admin auth
+	if c.Dashboard.Enabled {
+		if c.Admin.Password == "" {
+			return fmt.Errorf("dashboard requires ADMIN_PASSWORD to be set")
+		}
+		if !strings.HasPrefix(c.Dashboard.Path, "/") || c.Dashboard.Path == "/" {
+			return fmt.Errorf("invalid dashboard path: %s", c.Dashboard.Path)
+		}
+	}
+
+	// Счетчики SLI отдаются через /metrics
+	if c.SLO.File != "" && !c.Metrics.Enabled {
+		return fmt.Errorf("SLO tracking requires metrics to be enabled")
+	}
+
 	// Валидация log format
 	validLogFormats := map[string]bool{
 		"json": true,
@@ -111,6 +421,15 @@ func (c *Config) validate() error {
 		return fmt.Errorf("invalid log format: %s", c.Logging.Format)
 	}
 
+	if c.OpenAPI.UIScriptIntegrity != "" && !integrityPattern.MatchString(c.OpenAPI.UIScriptIntegrity) {
+		return fmt.Errorf("invalid docs script integrity %q: expected sha256-, sha384- or sha512- and a base64 digest", c.OpenAPI.UIScriptIntegrity)
+	}
+
+	// Проверка ответов буферизует тело и предназначена только для отладки
+	if c.OpenAPI.ValidateResponses && c.IsProduction() {
+		return fmt.Errorf("response validation must not be enabled in production")
+	}
+
 	return nil
 }
 
@@ -124,6 +443,16 @@ func (c *Config) IsDevelopment() bool {
 	return c.App.Environment == "development"
 }
 
+// validNetwork проверяет адрес (10.0.0.1) или подсеть (10.0.0.0/8)
+func validNetwork(s string) bool {
+	if strings.Contains(s, "/") {
+		_, err := netip.ParsePrefix(s)
+		return err == nil
+	}
+	_, err := netip.ParseAddr(s)
+	return err == nil
+}
+
 // getEnv возвращает значение переменной окружения или значение по умолчанию
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -142,6 +471,84 @@ func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// getIntEnv возвращает int из переменной окружения или значение по умолчанию
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// getFloatEnv возвращает float64 из переменной окружения или значение по умолчанию
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// hostname возвращает имя хоста (ID контейнера в Docker) или пустую строку
+func hostname() string {
+	name, _ := os.Hostname()
+	return name
+}
+
+// getListEnv возвращает непустые элементы списка через запятую из переменной окружения
+func getListEnv(key string) []string {
+	var result []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getMapEnv возвращает пары key=value через запятую из переменной окружения.
+// Элементы без "=" пропускаются.
+func getMapEnv(key string) map[string]string {
+	result := make(map[string]string)
+	for _, item := range getListEnv(key) {
+		if k, v, ok := strings.Cut(item, "="); ok && strings.TrimSpace(k) != "" {
+			result[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return result
+}
+
+// parseBuckets разбирает границы бакетов в формате
+// "http_request_duration_seconds=0.001,0.005,0.01;other=1,5,10"
+func parseBuckets(value string) (map[string][]float64, error) {
+	result := make(map[string][]float64)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, list, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("expected name=bucket,... got %q", entry)
+		}
+
+		var buckets []float64
+		for _, field := range strings.Split(list, ",") {
+			b, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, fmt.Errorf("metric %s: invalid bucket %q", name, field)
+			}
+			buckets = append(buckets, b)
+		}
+		result[name] = buckets
+	}
+	return result, nil
+}
+
 // getBoolEnv возвращает bool из переменной окружения или значение по умолчанию
 func getBoolEnv(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// RedactedValue подставляется вместо значений полей, помеченных тегом secret:"true".
+// У URL с тегом secret:"userinfo" скрывается только пароль (user:xxxxx@host).
+const RedactedValue = "[REDACTED]"
+
+// Redacted возвращает эффективную конфигурацию в виде вложенных map с ключами из json тегов.
+// Значения секретных полей заменяются на RedactedValue, длительности выводятся в формате time.Duration.
+func (c *Config) Redacted() map[string]interface{} {
+	return redactStruct(reflect.ValueOf(*c))
+}
+
+// redactStruct рекурсивно обходит структуру и собирает ее поля в map
+func redactStruct(v reflect.Value) map[string]interface{} {
+	out := make(map[string]interface{}, v.NumField())
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		secret := field.Tag.Get("secret")
+		if secret == "userinfo" {
+			out[name] = redactUserinfo(v.Field(i).String())
+			continue
+		}
+		out[name] = redactValue(v.Field(i), secret == "true")
+	}
+
+	return out
+}
+
+// redactUserinfo скрывает пароль в URL; неразбираемое значение с userinfo скрывается целиком
+func redactUserinfo(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		if strings.Contains(raw, "@") {
+			return RedactedValue
+		}
+		return raw
+	}
+	return u.Redacted()
+}
+
+// redactValue преобразует значение поля в пригодное для вывода представление
+func redactValue(v reflect.Value, secret bool) interface{} {
+	if secret {
+		if v.IsZero() {
+			return ""
+		}
+		return RedactedValue
+	}
+
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return redactStruct(v)
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem(), false)
+	case reflect.Slice:
+		if v.IsNil() {
+			return []interface{}{}
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = redactValue(v.Index(i), false)
+		}
+		return items
+	case reflect.Map:
+		items := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			items[reflect.ValueOf(iter.Key().Interface()).String()] = redactValue(iter.Value(), false)
+		}
+		return items
+	}
+
+	return v.Interface()
+}
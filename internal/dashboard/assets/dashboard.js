@@ -0,0 +1,167 @@
+// Страница состояния: данные приходят из /metrics/stream (SSE), /metrics/history,
+// /ready, /version и последних ошибок страницы (только для администратора). Значения вставляются через textContent, без innerHTML.
+(function () {
+  "use strict";
+
+  var urls = document.body.dataset;
+  var chartPoints = [];
+  var chartLimit = 90;
+
+  function $(id) { return document.getElementById(id); }
+
+  function text(id, value) { $(id).textContent = value; }
+
+  function ms(value) { return value.toFixed(value < 10 ? 2 : 0) + " ms"; }
+
+  function percent(value) { return (value * 100).toFixed(2) + "%"; }
+
+  function getJSON(url) {
+    return fetch(url, { headers: { Accept: "application/json" }, credentials: "same-origin" })
+      .then(function (resp) { return resp.json(); });
+  }
+
+  function row(cells, className) {
+    var tr = document.createElement("tr");
+    cells.forEach(function (value) {
+      var td = document.createElement("td");
+      td.textContent = value;
+      if (className) { td.className = className; }
+      tr.appendChild(td);
+    });
+    return tr;
+  }
+
+  function setBadge(id, label, ok) {
+    var el = $(id);
+    el.textContent = label;
+    el.className = "badge " + (ok ? "ok" : "fail");
+  }
+
+  function renderMetrics(m) {
+    text("uptime", m.uptime.replace(/\.\d+s$/, "s"));
+    text("started", "since " + new Date(m.start_time).toLocaleString());
+    text("in-flight", m.in_flight);
+    text("total", m.request_count + " requests total");
+
+    var minute = m.windows[0];
+    if (minute) {
+      text("rate", minute.request_rate.toFixed(2));
+      text("error-rate", percent(minute.error_rate));
+      text("p50", ms(minute.latency.p50_ms));
+      text("p90", ms(minute.latency.p90_ms));
+      text("p95", ms(minute.latency.p95_ms));
+      text("p99", ms(minute.latency.p99_ms));
+    }
+  }
+
+  function loadErrors() {
+    getJSON(urls.errorsUrl).then(function (r) {
+      renderErrors(r.errors);
+    });
+  }
+
+  function renderErrors(errors) {
+    var body = $("errors").tBodies[0];
+    body.replaceChildren();
+    if (errors.length === 0) {
+      var empty = row(["No errors"], "muted");
+      empty.firstChild.colSpan = 5;
+      body.appendChild(empty);
+    }
+    errors.forEach(function (e) {
+      body.appendChild(row([
+        new Date(e.timestamp).toLocaleTimeString(),
+        e.method + " " + e.path,
+        e.status,
+        ms(e.duration_ms),
+        e.request_id || ""
+      ]));
+    });
+  }
+
+  function addPoint(time, rate) {
+    chartPoints.push({ time: time, rate: rate });
+    if (chartPoints.length > chartLimit) { chartPoints.shift(); }
+    drawChart();
+  }
+
+  function drawChart() {
+    var canvas = $("rate-chart");
+    var ctx = canvas.getContext("2d");
+    ctx.clearRect(0, 0, canvas.width, canvas.height);
+    if (chartPoints.length < 2) { return; }
+
+    var max = Math.max.apply(null, chartPoints.map(function (p) { return p.rate; })) || 1;
+    var step = canvas.width / (chartLimit - 1);
+    var offset = chartLimit - chartPoints.length;
+
+    ctx.strokeStyle = "#0969da";
+    ctx.lineWidth = 2;
+    ctx.beginPath();
+    chartPoints.forEach(function (p, i) {
+      var x = (offset + i) * step;
+      var y = canvas.height - 10 - (p.rate / max) * (canvas.height - 20);
+      if (i === 0) { ctx.moveTo(x, y); } else { ctx.lineTo(x, y); }
+    });
+    ctx.stroke();
+
+    ctx.fillStyle = "#57606a";
+    ctx.font = "12px sans-serif";
+    ctx.fillText("max " + max.toFixed(2) + " req/s", 6, 14);
+  }
+
+  function loadHistory() {
+    return getJSON(urls.historyUrl + "?window=15m").then(function (h) {
+      h.points.forEach(function (p) { addPoint(p.timestamp, p.request_rate); });
+    });
+  }
+
+  function loadVersion() {
+    getJSON(urls.versionUrl).then(function (v) {
+      text("version", v.app_version);
+      text("commit", (v.commit || "unknown commit").slice(0, 12) + (v.dirty ? " (dirty)" : "") + ", " + v.go_version);
+    });
+  }
+
+  function loadReady() {
+    getJSON(urls.readyUrl).then(function (r) {
+      setBadge("ready-status", r.status, r.status === "READY");
+
+      var table = $("checks");
+      table.replaceChildren();
+      var names = Object.keys(r.checks || {}).sort();
+      if (names.length === 0) {
+        table.appendChild(row(["No checks registered"], "muted"));
+      }
+      names.forEach(function (name) {
+        var status = r.checks[name];
+        table.appendChild(row([name, status], status === "OK" ? "" : "fail"));
+      });
+    }).catch(function () {
+      setBadge("ready-status", "UNREACHABLE", false);
+    });
+  }
+
+  function connect() {
+    var source = new EventSource(urls.streamUrl);
+    source.addEventListener("open", function () { setBadge("connection", "live", true); });
+    source.addEventListener("error", function () { setBadge("connection", "reconnecting", false); });
+    source.addEventListener("metrics", function (event) {
+      var m = JSON.parse(event.data);
+      renderMetrics(m);
+      if (m.windows[0]) { addPoint(Date.now(), m.windows[0].request_rate); }
+    });
+    // Пропущенные во время разрыва точки истории (Last-Event-ID)
+    source.addEventListener("history", function (event) {
+      var p = JSON.parse(event.data);
+      addPoint(p.timestamp, p.request_rate);
+    });
+  }
+
+  loadVersion();
+  loadReady();
+  loadErrors();
+  setInterval(loadReady, 10000);
+  setInterval(loadErrors, 10000);
+  loadHistory().finally(connect);
+})();
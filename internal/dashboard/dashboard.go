@@ -0,0 +1,57 @@
+// Package dashboard отдает встроенную страницу состояния сервера: версия, окружение,
+// uptime, rate запросов, перцентили задержки, проверки готовности и последние ошибки.
+// Страница статическая и получает данные из JSON endpoints сервера.
+package dashboard
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+
+	"web-server-go-docker/internal/handlers"
+)
+
+//go:embed index.html
+var indexPage string
+
+//go:embed assets
+var assets embed.FS
+
+var indexTemplate = template.Must(template.New("dashboard").Parse(indexPage))
+
+// Page описывает адреса, с которыми работает страница
+type Page struct {
+	// Base - путь страницы, скрипты и стили отдаются из Base + "/assets/"
+	Base string
+	// Environment выводится в заголовке страницы
+	Environment string
+
+	StreamURL  string
+	HistoryURL string
+	ReadyURL   string
+	VersionURL string
+	// ErrorsURL - последние ошибки; в отличие от остальных адресов доступен только администратору
+	ErrorsURL string
+}
+
+// IndexHandler отдает HTML страницу состояния
+func IndexHandler(page Page) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := indexTemplate.Execute(w, page); err != nil {
+			handlers.InternalError(w, r, fmt.Errorf("rendering dashboard page: %w", err))
+		}
+	}
+}
+
+// AssetsHandler отдает скрипты и стили страницы по пути prefix + имя файла
+func AssetsHandler(prefix string) http.Handler {
+	files, err := fs.Sub(assets, "assets")
+	if err != nil {
+		panic(err)
+	}
+	return http.StripPrefix(prefix, http.FileServerFS(files))
+}
@@ -0,0 +1,66 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIndexHandler(t *testing.T) {
+	h := IndexHandler(Page{
+		Base:        "/status",
+		Environment: "<staging>",
+		StreamURL:   "/metrics/stream",
+		HistoryURL:  "/metrics/history",
+		ReadyURL:    "/ready",
+		VersionURL:  "/version",
+		ErrorsURL:   "/status/errors",
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`src="/status/assets/dashboard.js"`,
+		`data-stream-url="/metrics/stream"`,
+		`data-errors-url="/status/errors"`,
+		`&lt;staging&gt;`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %s", want)
+		}
+	}
+}
+
+func TestAssetsHandler(t *testing.T) {
+	h := AssetsHandler("/status/assets/")
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+	}{
+		{"/status/assets/dashboard.js", http.StatusOK, "text/javascript; charset=utf-8"},
+		{"/status/assets/dashboard.css", http.StatusOK, "text/css; charset=utf-8"},
+		{"/status/assets/missing.js", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, w.Code)
+			}
+			if tt.contentType != "" && w.Header().Get("Content-Type") != tt.contentType {
+				t.Errorf("expected %s, got %s", tt.contentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"web-server-go-docker/internal/requestid"
+)
+
+// CheckFunc - проверка зависимости для readiness endpoint.
+// Возвращает ошибку, если зависимость недоступна.
+type CheckFunc func(ctx context.Context) error
+
+// checkTimeout ограничивает время выполнения всех проверок одного запроса
+const checkTimeout = 2 * time.Second
+
+// namedCheck - зарегистрированная проверка
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// checkRegistry хранит проверки готовности
+type checkRegistry struct {
+	mu     sync.RWMutex
+	checks []namedCheck
+}
+
+// add регистрирует проверку
+func (cr *checkRegistry) add(name string, check CheckFunc) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.checks = append(cr.checks, namedCheck{name: name, check: check})
+}
+
+// run выполняет все проверки параллельно и возвращает их статусы.
+// Проверка, не уложившаяся в checkTimeout, считается упавшей; ее результат
+// не ожидается. Текст ошибок пишется только в лог, чтобы не раскрывать
+// детали зависимостей в ответе /ready.
+func (cr *checkRegistry) run(ctx context.Context) (map[string]string, bool) {
+	cr.mu.RLock()
+	checks := append([]namedCheck(nil), cr.checks...)
+	cr.mu.RUnlock()
+
+	if len(checks) == 0 {
+		return nil, true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	type result struct {
+		name string
+		err  error
+	}
+
+	// Буфер позволяет зависшим проверкам завершиться без получателя
+	results := make(chan result, len(checks))
+	for _, c := range checks {
+		go func(c namedCheck) {
+			results <- result{name: c.name, err: c.check(ctx)}
+		}(c)
+	}
+
+	statuses := make(map[string]string, len(checks))
+	healthy := true
+	for range checks {
+		select {
+		case res := <-results:
+			if res.err != nil {
+				log.Printf("Readiness check %s failed (request_id=%s): %v",
+					res.name, requestid.FromContext(ctx), res.err)
+				statuses[res.name] = "FAIL"
+				healthy = false
+				continue
+			}
+			statuses[res.name] = "OK"
+		case <-ctx.Done():
+			for _, c := range checks {
+				if _, ok := statuses[c.name]; !ok {
+					log.Printf("Readiness check %s timed out (request_id=%s)",
+						c.name, requestid.FromContext(ctx))
+					statuses[c.name] = "FAIL: timeout"
+				}
+			}
+			return statuses, false
+		}
+	}
+
+	return statuses, healthy
+}
+
+// names возвращает имена зарегистрированных проверок
+func (cr *checkRegistry) names() []string {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	names := make([]string, 0, len(cr.checks))
+	for _, c := range cr.checks {
+		names = append(names, c.name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -2,125 +2,312 @@
 package handlers
 
 import (
-	"encoding/json"
-	"log"
+	"fmt"
 	"net/http"
+	"sync/atomic"
 	"time"
 
+	"web-server-go-docker/internal/buildinfo"
 	"web-server-go-docker/internal/config"
 	"web-server-go-docker/internal/metrics"
 	"web-server-go-docker/internal/models"
+	"web-server-go-docker/internal/stats"
 )
 
 // Handler содержит зависимости для обработчиков
 type Handler struct {
 	config        *config.Config
 	metrics       *metrics.Metrics
-	requestCount  *int
+	stats         *stats.Stats
+	history       *stats.History
+	streams       *streamHub
+	ready         atomic.Bool
+	checks        checkRegistry
+	encoders      *Encoders
 }
 
 // New создает новый Handler с зависимостями
-func New(cfg *config.Config, m *metrics.Metrics, requestCount *int) *Handler {
+func New(cfg *config.Config, m *metrics.Metrics, st *stats.Stats) *Handler {
 	return &Handler{
-		config:       cfg,
-		metrics:      m,
-		requestCount: requestCount,
+		config:   cfg,
+		metrics:  m,
+		stats:    st,
+		encoders: NewEncoders(),
+		streams:  newStreamHub(),
 	}
 }
 
+// SetHistory подключает историю статистики для MetricsHistory
+func (h *Handler) SetHistory(history *stats.History) {
+	h.history = history
+}
+
+// RegisterEncoder добавляет формат ответа для content negotiation
+// (или заменяет встроенный с тем же media type)
+func (h *Handler) RegisterEncoder(enc Encoder, aliases ...string) {
+	h.encoders.Register(enc, aliases...)
+}
+
+// MediaTypes возвращает media type, которые обработчики умеют отдавать
+func (h *Handler) MediaTypes() []string {
+	return h.encoders.MediaTypes()
+}
+
+// Обработчики не проверяют метод и путь: это делает router при регистрации маршрута.
+// Формат ответа (JSON, YAML, text, HTML) выбирается по заголовку Accept в respond.
+
 // Health обрабатывает health check запросы
 func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
 	response := models.HealthResponse{
 		Status:    "OK",
 		Timestamp: time.Now().Format(time.RFC3339),
 		Version:   h.config.App.Version,
 	}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding health response: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
-	}
+	h.respond(w, r, http.StatusOK, response)
 }
 
-// Info обрабатывает info запросы
-func (h *Handler) Info(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/" {
-		http.NotFound(w, r)
-		return
+// SetReady переключает состояние готовности, которое отдает Ready
+func (h *Handler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// AddCheck регистрирует проверку зависимости, выполняемую в Ready
+func (h *Handler) AddCheck(name string, check CheckFunc) {
+	h.checks.add(name, check)
+}
+
+// CheckNames возвращает имена зарегистрированных проверок
+func (h *Handler) CheckNames() []string {
+	return h.checks.names()
+}
+
+// Ready обрабатывает readiness запросы: 503 до запуска, во время остановки
+// сервера и если хотя бы одна из зарегистрированных проверок не прошла
+func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
+	status := "READY"
+	code := http.StatusOK
+
+	var checks map[string]string
+	healthy := h.ready.Load()
+	if healthy {
+		checks, healthy = h.checks.run(r.Context())
+	}
+	if !healthy {
+		status = "NOT_READY"
+		code = http.StatusServiceUnavailable
 	}
 
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+	response := models.HealthResponse{
+		Status:    status,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Version:   h.config.App.Version,
+		Checks:    checks,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	h.respond(w, r, code, response)
+}
+
+// Info обрабатывает info запросы
+func (h *Handler) Info(w http.ResponseWriter, r *http.Request) {
 	response := models.InfoResponse{
 		Message:     "DevOps Portfolio 2025 - Go Web Server",
 		Environment: h.config.App.Environment,
 		Port:        h.config.Server.Port,
 	}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding info response: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+	h.respond(w, r, http.StatusOK, response)
+}
+
+// Version обрабатывает version запросы: возвращает сведения о сборке бинарника
+func (h *Handler) Version(w http.ResponseWriter, r *http.Request) {
+	info := buildinfo.Get()
+
+	deps := make([]models.DependencyInfo, 0, len(info.Dependencies))
+	for _, d := range info.Dependencies {
+		deps = append(deps, models.DependencyInfo{
+			Path:    d.Path,
+			Version: d.Version,
+			Sum:     d.Sum,
+			Replace: d.Replace,
+		})
 	}
+
+	response := models.VersionResponse{
+		Version:      info.Version,
+		AppVersion:   h.config.App.Version,
+		Commit:       info.Commit,
+		Dirty:        info.Dirty,
+		BuildTime:    info.BuildTime,
+		CommitTime:   info.CommitTime,
+		GoVersion:    info.GoVersion,
+		Module:       info.Module,
+		Dependencies: deps,
+	}
+
+	h.respond(w, r, http.StatusOK, response)
 }
 
 // Metrics обрабатывает metrics запросы (JSON формат)
 func (h *Handler) Metrics(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+	h.respond(w, r, http.StatusOK, h.metricsResponse())
+}
+
+// metricsResponse собирает JSON метрики из текущей статистики
+func (h *Handler) metricsResponse() models.MetricsResponse {
+	snap := h.stats.Snapshot()
+
+	response := models.MetricsResponse{
+		RequestCount: int(snap.Total),
+		Uptime:       snap.Uptime.String(),
+		StartTime:    snap.StartTime.Format(time.RFC3339),
+		InFlight:     snap.InFlight,
+		ErrorCount:   snap.Errors,
+		ErrorRate:    snap.ErrorRate,
+		StatusCodes:  snap.Statuses,
+		Routes:       make([]models.RouteMetrics, 0, len(snap.Routes)),
+		Windows:      make([]models.WindowMetrics, 0, len(snap.Windows)),
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	
-	var requestCount int
-	if h.requestCount != nil {
-		requestCount = *h.requestCount
+	for _, route := range snap.Routes {
+		response.Routes = append(response.Routes, models.RouteMetrics{
+			Route:        route.Route,
+			Requests:     route.Requests,
+			Errors:       route.Errors,
+			ErrorRate:    route.ErrorRate,
+			AvgLatencyMs: milliseconds(route.AvgLatency),
+			MaxLatencyMs: milliseconds(route.MaxLatency),
+		})
 	}
 
-	var startTime time.Time
-	var uptime string
-	if h.metrics != nil {
-		startTime = h.metrics.GetStartTime()
-		uptime = time.Since(startTime).String()
-	} else {
-		startTime = time.Now()
-		uptime = "0s"
+	for _, window := range snap.Windows {
+		response.Windows = append(response.Windows, windowMetrics(window))
 	}
 
-	response := models.MetricsResponse{
-		RequestCount: requestCount,
-		Uptime:       uptime,
-		StartTime:    startTime.Format(time.RFC3339),
+	return response
+}
+
+// RecentErrors отдает последние запросы с ответом 5xx. Пути и идентификаторы
+// запросов не публикуются в /metrics, маршрут регистрируется под авторизацией.
+func (h *Handler) RecentErrors(w http.ResponseWriter, r *http.Request) {
+	recent := h.stats.RecentErrors()
+	response := models.RecentErrorsResponse{Errors: make([]models.ErrorInfo, 0, len(recent))}
+	for _, e := range recent {
+		response.Errors = append(response.Errors, models.ErrorInfo{
+			Timestamp:  e.Time.Format(time.RFC3339),
+			Method:     e.Method,
+			Path:       e.Path,
+			Route:      e.Route,
+			Status:     e.Status,
+			RequestID:  e.RequestID,
+			DurationMs: milliseconds(e.Duration),
+		})
 	}
+	h.respond(w, r, http.StatusOK, response)
+}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding metrics response: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+// MetricsHistory обрабатывает запросы истории метрик.
+// Query параметры: window - глубина (по умолчанию вся история),
+// resolution - шаг точек (по умолчанию интервал истории, округляется вверх до кратного).
+func (h *Handler) MetricsHistory(w http.ResponseWriter, r *http.Request) {
+	if h.history == nil {
+		ServiceUnavailable(w, r, "metrics history is disabled")
 		return
 	}
-}
 
-// PrometheusMetrics обрабатывает Prometheus metrics запросы
-func (h *Handler) PrometheusMetrics(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	window := h.history.Retention()
+	resolution := h.history.Resolution()
+
+	var errs []FieldError
+	query := r.URL.Query()
+	if value := query.Get("window"); value != "" {
+		d, err := time.ParseDuration(value)
+		switch {
+		case err != nil:
+			errs = append(errs, FieldError{Field: "query.window", Message: "must be a duration, e.g. 15m"})
+		case d < h.history.Resolution() || d > h.history.Retention():
+			errs = append(errs, FieldError{Field: "query.window",
+				Message: fmt.Sprintf("must be between %s and %s", h.history.Resolution(), h.history.Retention())})
+		default:
+			window = d
+		}
+	}
+	if value := query.Get("resolution"); value != "" {
+		d, err := time.ParseDuration(value)
+		switch {
+		case err != nil:
+			errs = append(errs, FieldError{Field: "query.resolution", Message: "must be a duration, e.g. 1m"})
+		case d <= 0:
+			errs = append(errs, FieldError{Field: "query.resolution", Message: "must be positive"})
+		default:
+			// Шаг округляется вверх до кратного интервалу истории
+			step := h.history.Resolution()
+			resolution = (d + step - 1) / step * step
+		}
+	}
+	if len(errs) > 0 {
+		ValidationFailed(w, r, errs)
 		return
 	}
 
+	points := h.history.Points(window, resolution)
+	response := models.MetricsHistoryResponse{
+		Window:     window.String(),
+		Resolution: resolution.String(),
+		Points:     make([]models.HistoryPoint, 0, len(points)),
+	}
+	for _, p := range points {
+		response.Points = append(response.Points, historyPoint(p))
+	}
+
+	h.respond(w, r, http.StatusOK, response)
+}
+
+// historyPoint преобразует точку истории в модель ответа
+func historyPoint(p stats.Point) models.HistoryPoint {
+	return models.HistoryPoint{
+		Timestamp:      p.Time.Format(time.RFC3339),
+		Requests:       p.Requests,
+		Errors:         p.Errors,
+		RequestRate:    p.Rate,
+		ErrorRate:      p.ErrorRate,
+		Latency:        latencyPercentiles(p.Latency),
+		HeapAllocBytes: p.HeapAlloc,
+		Goroutines:     p.Goroutines,
+	}
+}
+
+// windowMetrics преобразует статистику окна в модель ответа
+func windowMetrics(window stats.WindowSnapshot) models.WindowMetrics {
+	return models.WindowMetrics{
+		Window:      window.Window.String(),
+		Requests:    window.Requests,
+		Errors:      window.Errors,
+		RequestRate: window.Rate,
+		ErrorRate:   window.ErrorRate,
+		Latency:     latencyPercentiles(window.Latency),
+	}
+}
+
+// latencyPercentiles переводит перцентили в миллисекунды
+func latencyPercentiles(p stats.Percentiles) models.LatencyPercentiles {
+	return models.LatencyPercentiles{
+		P50: milliseconds(p.P50),
+		P90: milliseconds(p.P90),
+		P95: milliseconds(p.P95),
+		P99: milliseconds(p.P99),
+	}
+}
+
+// milliseconds переводит длительность в дробные миллисекунды
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// PrometheusMetrics обрабатывает Prometheus metrics запросы
+func (h *Handler) PrometheusMetrics(w http.ResponseWriter, r *http.Request) {
 	if h.metrics == nil {
-		http.Error(w, "Metrics not available", http.StatusServiceUnavailable)
+		ServiceUnavailable(w, r, "Prometheus metrics are disabled")
 		return
 	}
 
@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"web-server-go-docker/internal/requestid"
+)
+
+// Encoder сериализует модель ответа в конкретный media type
+type Encoder interface {
+	// MediaType возвращает основной media type, например "application/json"
+	MediaType() string
+	// Encode записывает v в w. pretty включает форматирование, если формат его поддерживает.
+	Encode(w io.Writer, v interface{}, pretty bool) error
+}
+
+// Encoders - реестр энкодеров для content negotiation.
+// Первый зарегистрированный используется, когда Accept пустой или равен */*.
+type Encoders struct {
+	encoders []Encoder
+	aliases  map[string]Encoder
+}
+
+// NewEncoders создает реестр со стандартными форматами: JSON, YAML, text и HTML
+func NewEncoders() *Encoders {
+	e := &Encoders{}
+	e.Register(JSONEncoder{})
+	e.Register(YAMLEncoder{}, "application/x-yaml", "text/yaml")
+	e.Register(TextEncoder{})
+	e.Register(HTMLEncoder{})
+	return e
+}
+
+// Register добавляет энкодер (или заменяет энкодер с тем же media type).
+// aliases - дополнительные media type, которые обслуживает энкодер.
+func (e *Encoders) Register(enc Encoder, aliases ...string) {
+	if e.aliases == nil {
+		e.aliases = make(map[string]Encoder)
+	}
+
+	replaced := false
+	for i, existing := range e.encoders {
+		if existing.MediaType() == enc.MediaType() {
+			e.encoders[i] = enc
+			replaced = true
+		}
+	}
+	if !replaced {
+		e.encoders = append(e.encoders, enc)
+	}
+
+	e.aliases[enc.MediaType()] = enc
+	for _, alias := range aliases {
+		e.aliases[strings.ToLower(alias)] = enc
+	}
+}
+
+// MediaTypes возвращает основные media type зарегистрированных энкодеров
+func (e *Encoders) MediaTypes() []string {
+	types := make([]string, 0, len(e.encoders))
+	for _, enc := range e.encoders {
+		types = append(types, enc.MediaType())
+	}
+	return types
+}
+
+// Negotiate выбирает энкодер по заголовку Accept с учетом q-факторов.
+// Range с q=0 исключают подходящие энкодеры при разрешении */* и type/*.
+// Возвращает false, если ни один из форматов не подходит.
+func (e *Encoders) Negotiate(accept string) (Encoder, bool) {
+	if len(e.encoders) == 0 {
+		return nil, false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return e.encoders[0], true
+	}
+
+	ranges, exclusions := parseAccept(accept)
+	for _, mediaRange := range ranges {
+		if specificity(mediaRange) == 2 {
+			if enc, ok := e.aliases[mediaRange]; ok {
+				return enc, true
+			}
+			continue
+		}
+		for _, enc := range e.encoders {
+			if matchesRange(mediaRange, enc.MediaType()) && !excluded(exclusions, mediaRange, enc.MediaType()) {
+				return enc, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// excluded сообщает, исключен ли mediaType диапазоном с q=0,
+// который конкретнее разрешаемого wildcard (text/*;q=0 не действует на text/html,
+// но действует на */*)
+func excluded(exclusions []string, wildcard, mediaType string) bool {
+	for _, exclusion := range exclusions {
+		if specificity(exclusion) > specificity(wildcard) && matchesRange(exclusion, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesRange сообщает, входит ли mediaType в media range
+func matchesRange(mediaRange, mediaType string) bool {
+	switch specificity(mediaRange) {
+	case 0:
+		return true
+	case 1:
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*"))
+	default:
+		return mediaRange == mediaType
+	}
+}
+
+// specificity возвращает 2 для type/subtype, 1 для type/* и 0 для */*
+func specificity(mediaRange string) int {
+	switch {
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*"):
+		return 1
+	}
+	return 2
+}
+
+// parseAccept разбирает заголовок Accept и возвращает media range,
+// отсортированные по убыванию q, а при равном q - от более конкретных
+// к менее конкретным: type/subtype, type/*, */* (RFC 9110, 12.5.1).
+// Range с q=0 возвращаются отдельно как исключения.
+func parseAccept(accept string) (ranges, exclusions []string) {
+	type weighted struct {
+		mediaRange string
+		q          float64
+	}
+
+	var parsed []weighted
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				q = v
+			}
+		}
+		if q <= 0 {
+			exclusions = append(exclusions, mediaType)
+			continue
+		}
+		parsed = append(parsed, weighted{mediaRange: mediaType, q: q})
+	}
+
+	sort.SliceStable(parsed, func(i, j int) bool {
+		if parsed[i].q != parsed[j].q {
+			return parsed[i].q > parsed[j].q
+		}
+		return specificity(parsed[i].mediaRange) > specificity(parsed[j].mediaRange)
+	})
+
+	ranges = make([]string, len(parsed))
+	for i, r := range parsed {
+		ranges[i] = r.mediaRange
+	}
+	return ranges, exclusions
+}
+
+// respond сериализует v в формат, выбранный по заголовку Accept.
+// Если формат не поддерживается, отдает 406 problem+json со списком доступных типов.
+func (h *Handler) respond(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	enc, ok := h.encoders.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		NotAcceptable(w, r, h.encoders.MediaTypes())
+		return
+	}
+
+	// ?pretty доступен только в development, чтобы не раздувать ответы в production
+	pretty := false
+	if h.config.IsDevelopment() {
+		if _, ok := r.URL.Query()["pretty"]; ok {
+			pretty = r.URL.Query().Get("pretty") != "false"
+		}
+	}
+
+	contentType := enc.MediaType()
+	if strings.HasPrefix(contentType, "text/") {
+		contentType += "; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+
+	if err := enc.Encode(w, v, pretty); err != nil {
+		// Заголовки уже отправлены, поэтому остается только записать ошибку в лог
+		log.Printf("Error encoding %s response (request_id=%s): %v",
+			enc.MediaType(), requestid.FromContext(r.Context()), err)
+	}
+}
+
+// JSONEncoder кодирует ответы в application/json
+type JSONEncoder struct{}
+
+// MediaType возвращает "application/json"
+func (JSONEncoder) MediaType() string { return "application/json" }
+
+// Encode записывает v как JSON
+func (JSONEncoder) Encode(w io.Writer, v interface{}, pretty bool) error {
+	enc := json.NewEncoder(w)
+	if pretty {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
+
+// YAMLEncoder кодирует ответы в application/yaml с теми же именами полей, что и JSON
+type YAMLEncoder struct{}
+
+// MediaType возвращает "application/yaml"
+func (YAMLEncoder) MediaType() string { return "application/yaml" }
+
+// Encode записывает v как YAML
+func (YAMLEncoder) Encode(w io.Writer, v interface{}, _ bool) error {
+	generic, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(generic); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// TextEncoder кодирует ответы в text/plain строками "ключ: значение"
+type TextEncoder struct{}
+
+// MediaType возвращает "text/plain"
+func (TextEncoder) MediaType() string { return "text/plain" }
+
+// Encode записывает поля v построчно, вложенные ключи объединяются через точку
+func (TextEncoder) Encode(w io.Writer, v interface{}, _ bool) error {
+	fields, err := flatten(v)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if _, err := fmt.Fprintf(w, "%s: %s\n", f.Key, f.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HTMLEncoder кодирует ответы в небольшую HTML страницу с таблицей полей
+type HTMLEncoder struct{}
+
+// MediaType возвращает "text/html"
+func (HTMLEncoder) MediaType() string { return "text/html" }
+
+var htmlPage = template.Must(template.New("response").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Go Web Server</title>
+<style>
+body { font-family: sans-serif; margin: 2rem; }
+table { border-collapse: collapse; }
+td { border: 1px solid #ddd; padding: 0.3rem 0.6rem; }
+td:first-child { font-weight: bold; }
+</style>
+</head>
+<body>
+<table>
+{{- range . }}
+<tr><td>{{ .Key }}</td><td>{{ .Value }}</td></tr>
+{{- end }}
+</table>
+</body>
+</html>
+`))
+
+// Encode записывает поля v в HTML таблицу (значения экранируются html/template)
+func (HTMLEncoder) Encode(w io.Writer, v interface{}, _ bool) error {
+	fields, err := flatten(v)
+	if err != nil {
+		return err
+	}
+	return htmlPage.Execute(w, fields)
+}
+
+// field - строка плоского представления модели
+type field struct {
+	Key   string
+	Value string
+}
+
+// toGeneric преобразует модель в map/slice через JSON, чтобы сохранить json теги
+func toGeneric(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// flatten превращает модель в отсортированный список "ключ: значение".
+// Порядок полей объектов совпадает с алфавитным, элементы массивов нумеруются.
+func flatten(v interface{}) ([]field, error) {
+	generic, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []field
+	var walk func(prefix string, value interface{})
+	walk = func(prefix string, value interface{}) {
+		switch typed := value.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(typed))
+			for key := range typed {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				walk(joinKey(prefix, key), typed[key])
+			}
+		case []interface{}:
+			for i, item := range typed {
+				walk(joinKey(prefix, strconv.Itoa(i)), item)
+			}
+		case float64:
+			fields = append(fields, field{Key: prefix, Value: strconv.FormatFloat(typed, 'f', -1, 64)})
+		case nil:
+			fields = append(fields, field{Key: prefix, Value: ""})
+		default:
+			fields = append(fields, field{Key: prefix, Value: fmt.Sprint(typed)})
+		}
+	}
+	walk("", generic)
+
+	return fields, nil
+}
+
+// joinKey объединяет ключи вложенных полей через точку
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"web-server-go-docker/internal/requestid"
+)
+
+// ProblemContentType - media type ответов об ошибках (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// Problem представляет ответ об ошибке в формате RFC 7807
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+
+	// Errors содержит ошибки валидации отдельных полей
+	Errors []FieldError `json:"errors,omitempty"`
+
+	// headers содержит дополнительные заголовки ответа (Allow, Retry-After, WWW-Authenticate)
+	headers http.Header
+}
+
+// FieldError описывает ошибку в конкретном поле запроса или ответа
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// NewProblem создает Problem с типом about:blank и стандартным заголовком для статуса
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Error реализует интерфейс error
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return fmt.Sprintf("%d %s", p.Status, p.Title)
+	}
+	return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+}
+
+// WithHeader добавляет заголовок, который будет отправлен вместе с ответом
+func (p *Problem) WithHeader(key, value string) *Problem {
+	if p.headers == nil {
+		p.headers = make(http.Header)
+	}
+	p.headers.Add(key, value)
+	return p
+}
+
+// WriteProblem отправляет Problem клиенту, дополняя его instance и идентификатором запроса
+func WriteProblem(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if p.Instance == "" && r != nil {
+		p.Instance = r.URL.Path
+	}
+	if p.RequestID == "" && r != nil {
+		p.RequestID = requestid.FromContext(r.Context())
+	}
+
+	for key, values := range p.headers {
+		for _, v := range values {
+			w.Header().Add(key, v)
+		}
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+
+	if r != nil && r.Method == http.MethodHead {
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		log.Printf("Error encoding problem response: %v", err)
+	}
+}
+
+// NotFound отправляет 404
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	WriteProblem(w, r, NewProblem(http.StatusNotFound, fmt.Sprintf("no resource at %s", r.URL.Path)))
+}
+
+// MethodNotAllowed отправляет 405 с заголовком Allow
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request, allowed ...string) {
+	p := NewProblem(http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not allowed", r.Method))
+	if len(allowed) > 0 {
+		p.WithHeader("Allow", strings.Join(allowed, ", "))
+	}
+	WriteProblem(w, r, p)
+}
+
+// InternalError логирует ошибку и отправляет 500 без раскрытия деталей клиенту
+func InternalError(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("Internal error on %s %s (request_id=%s): %v",
+		r.Method, r.URL.Path, requestid.FromContext(r.Context()), err)
+	WriteProblem(w, r, NewProblem(http.StatusInternalServerError, ""))
+}
+
+// ValidationFailed отправляет 400 со списком ошибок по полям
+func ValidationFailed(w http.ResponseWriter, r *http.Request, errs []FieldError) {
+	p := NewProblem(http.StatusBadRequest, "request validation failed")
+	p.Errors = errs
+	WriteProblem(w, r, p)
+}
+
+// NotAcceptable отправляет 406 со списком поддерживаемых media type
+func NotAcceptable(w http.ResponseWriter, r *http.Request, supported []string) {
+	detail := "supported media types: " + strings.Join(supported, ", ")
+	WriteProblem(w, r, NewProblem(http.StatusNotAcceptable, detail).WithHeader("Vary", "Accept"))
+}
+
+// ServiceUnavailable отправляет 503
+func ServiceUnavailable(w http.ResponseWriter, r *http.Request, detail string) {
+	WriteProblem(w, r, NewProblem(http.StatusServiceUnavailable, detail))
+}
+
+// TooManyRequests отправляет 429 с заголовком Retry-After
+func TooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	p := NewProblem(http.StatusTooManyRequests, "rate limit exceeded")
+	if retryAfter > 0 {
+		seconds := int((retryAfter + time.Second - 1) / time.Second)
+		p.WithHeader("Retry-After", strconv.Itoa(seconds))
+	}
+	WriteProblem(w, r, p)
+}
+
+// Unauthorized отправляет 401 с заголовком WWW-Authenticate
+func Unauthorized(w http.ResponseWriter, r *http.Request, challenge, detail string) {
+	p := NewProblem(http.StatusUnauthorized, detail)
+	if challenge != "" {
+		p.WithHeader("WWW-Authenticate", challenge)
+	}
+	WriteProblem(w, r, p)
+}
+
+// Forbidden отправляет 403
+func Forbidden(w http.ResponseWriter, r *http.Request, detail string) {
+	WriteProblem(w, r, NewProblem(http.StatusForbidden, detail))
+}
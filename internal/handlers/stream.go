@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Ограничения потока метрик
+const (
+	MinStreamInterval = time.Second
+	MaxStreamInterval = time.Minute
+
+	defaultStreamInterval       = 5 * time.Second
+	defaultStreamMaxSubscribers = 100
+
+	// heartbeatInterval - период комментариев, не дающих прокси закрыть простаивающее соединение
+	heartbeatInterval = 15 * time.Second
+	// streamWriteTimeout - дедлайн одной записи в поток (WriteTimeout сервера для потока не действует)
+	streamWriteTimeout = 10 * time.Second
+)
+
+// streamHub учитывает подписчиков потока и закрывает их при остановке сервера
+type streamHub struct {
+	subscribers atomic.Int64
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+func newStreamHub() *streamHub {
+	return &streamHub{done: make(chan struct{})}
+}
+
+// CloseStreams завершает все открытые потоки метрик. http.Server.Shutdown не
+// прерывает активные запросы, поэтому вызывается через RegisterOnShutdown.
+func (h *Handler) CloseStreams() {
+	h.streams.closeOnce.Do(func() {
+		close(h.streams.done)
+	})
+}
+
+// MetricsStream отдает JSON метрики как Server-Sent Events каждые interval.
+// При переподключении с Last-Event-ID сначала отправляются пропущенные точки истории.
+//
+// События:
+//
+//	event: metrics  - models.MetricsResponse
+//	event: history  - models.HistoryPoint (только при возобновлении)
+//
+// id события - время в миллисекундах Unix.
+func (h *Handler) MetricsStream(w http.ResponseWriter, r *http.Request) {
+	interval := h.config.Metrics.StreamInterval
+	if interval <= 0 {
+		interval = defaultStreamInterval
+	}
+	if value := r.URL.Query().Get("interval"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < MinStreamInterval || d > MaxStreamInterval {
+			ValidationFailed(w, r, []FieldError{{
+				Field:   "query.interval",
+				Message: fmt.Sprintf("must be a duration between %s and %s", MinStreamInterval, MaxStreamInterval),
+			}})
+			return
+		}
+		interval = d
+	}
+
+	// Router обслуживает HEAD обработчиком GET: отдаем только заголовки потока,
+	// не занимая место подписчика
+	if r.Method == http.MethodHead {
+		setStreamHeaders(w)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	limit := int64(h.config.Metrics.StreamMaxSubscribers)
+	if limit <= 0 {
+		limit = defaultStreamMaxSubscribers
+	}
+	if h.streams.subscribers.Add(1) > limit {
+		h.streams.subscribers.Add(-1)
+		WriteProblem(w, r, NewProblem(http.StatusServiceUnavailable, "too many metrics stream subscribers").
+			WithHeader("Retry-After", strconv.Itoa(int(interval.Seconds()))))
+		return
+	}
+	defer h.streams.subscribers.Add(-1)
+
+	rc := http.NewResponseController(w)
+	setStreamHeaders(w)
+	w.WriteHeader(http.StatusOK)
+
+	// write продлевает дедлайн записи и сразу отправляет данные клиенту
+	write := func(format string, args ...interface{}) error {
+		if err := rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+	send := func(event string, at time.Time, v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return write("id: %d\nevent: %s\ndata: %s\n\n", at.UnixMilli(), event, data)
+	}
+
+	if err := write("retry: %d\n\n", interval.Milliseconds()); err != nil {
+		return
+	}
+
+	if lastID, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64); err == nil && h.history != nil {
+		for _, p := range h.history.Since(time.UnixMilli(lastID)) {
+			if err := send("history", p.Time, historyPoint(p)); err != nil {
+				return
+			}
+		}
+	}
+
+	if err := send("metrics", time.Now(), h.metricsResponse()); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.streams.done:
+			return
+		case now := <-ticker.C:
+			err = send("metrics", now, h.metricsResponse())
+		case <-heartbeat.C:
+			err = write(": heartbeat\n\n")
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// StreamSubscribers возвращает количество открытых потоков метрик
+func (h *Handler) StreamSubscribers() int64 {
+	return h.streams.subscribers.Load()
+}
+
+// setStreamHeaders устанавливает заголовки ответа Server-Sent Events
+func setStreamHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+}
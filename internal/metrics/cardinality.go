@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Overflow - значение всех меток серии, в которую сводятся наблюдения сверх предела
+const Overflow = "other"
+
+// DefaultMaxSeries - предел числа комбинаций меток одной метрики
+const DefaultMaxSeries = 1000
+
+// maxLoggedOverflows - сколько первых превышений предела логируется для каждой метрики
+const maxLoggedOverflows = 5
+
+// cardinalityGuard ограничивает число комбинаций меток метрики. Новые комбинации
+// сверх предела записываются в серию, где все метки равны Overflow, и считаются
+// в metrics_cardinality_overflow_total{metric}. Так клиент, подставляющий
+// произвольные методы или пути, не может раздуть число серий в Prometheus.
+type cardinalityGuard struct {
+	name     string
+	labels   int
+	limit    int
+	overflow prometheus.Counter
+
+	mu      sync.Mutex
+	series  map[string]struct{}
+	logged  int
+	invalid bool
+}
+
+func newCardinalityGuard(name string, labels, limit int, overflow *prometheus.CounterVec) *cardinalityGuard {
+	return &cardinalityGuard{
+		name:     name,
+		labels:   labels,
+		limit:    limit,
+		overflow: overflow.WithLabelValues(name),
+		series:   make(map[string]struct{}),
+	}
+}
+
+// values возвращает значения меток для записи: исходные или сведенные в Overflow.
+// ok = false, если число значений не совпадает с числом меток (ошибка вызова).
+func (g *cardinalityGuard) values(values []string) (_ []string, ok bool) {
+	if len(values) != g.labels {
+		g.logInvalid(fmt.Sprintf("expected %d label values, got %d", g.labels, len(values)), values)
+		return nil, false
+	}
+	for _, v := range values {
+		// Невалидный UTF-8 Prometheus client отвергает паникой
+		if !utf8.ValidString(v) {
+			return g.collapse(values, "label value is not valid UTF-8"), true
+		}
+	}
+
+	key := strings.Join(values, "\xff")
+
+	g.mu.Lock()
+	if _, seen := g.series[key]; seen || len(g.series) < g.limit {
+		g.series[key] = struct{}{}
+		g.mu.Unlock()
+		return values, true
+	}
+	g.mu.Unlock()
+
+	return g.collapse(values, fmt.Sprintf("series limit %d reached", g.limit)), true
+}
+
+// collapse считает превышение и возвращает значения серии Overflow
+func (g *cardinalityGuard) collapse(values []string, reason string) []string {
+	g.overflow.Inc()
+
+	g.mu.Lock()
+	first := g.logged < maxLoggedOverflows
+	g.logged++
+	g.mu.Unlock()
+	if first {
+		logOverflow(g.name, values, reason)
+	}
+
+	collapsed := make([]string, len(values))
+	for i := range collapsed {
+		collapsed[i] = Overflow
+	}
+	return collapsed
+}
+
+// logInvalid логирует первый неверный вызов метрики
+func (g *cardinalityGuard) logInvalid(reason string, values []string) {
+	g.mu.Lock()
+	first := !g.invalid
+	g.invalid = true
+	g.mu.Unlock()
+	if first {
+		log.Printf("Metric %s: dropping observation with labels %q: %s (further drops are not logged)", g.name, values, reason)
+	}
+}
+
+// maxLoggedValue - длина, до которой обрезаются значения меток в логе
+const maxLoggedValue = 128
+
+// logOverflow логирует значения меток, сведенные в Overflow
+func logOverflow(name string, values []string, reason string) {
+	logged := make([]string, len(values))
+	for i, v := range values {
+		if len(v) > maxLoggedValue {
+			v = v[:maxLoggedValue] + "..."
+		}
+		logged[i] = v
+	}
+	log.Printf("Metric %s: label values %q recorded as %q: %s", name, logged, Overflow, reason)
+}
@@ -0,0 +1,225 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	metricNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	labelNamePattern  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+	// reservedLabels заняты Prometheus (метки цели) или типами метрик (le, quantile)
+	reservedLabels = map[string]bool{"job": true, "instance": true, "le": true, "quantile": true}
+)
+
+// Opts описывает пользовательскую метрику. Полное имя - namespace из
+// конфигурации и Name через "_": orders_created_total.
+type Opts struct {
+	Name   string
+	Help   string
+	Labels []string
+	// MaxSeries - предел комбинаций значений меток, сверх него значения меток
+	// заменяются на Overflow; 0 - значение из WithSeriesLimit
+	MaxSeries int
+}
+
+// HistogramOpts описывает пользовательскую гистограмму
+type HistogramOpts struct {
+	Opts
+	// Buckets - границы бакетов; WithBuckets по полному имени имеет приоритет,
+	// без обоих используются prometheus.DefBuckets
+	Buckets []float64
+}
+
+// Counter - пользовательский счетчик. Счетчик, созданный при отключенных
+// метриках (nil *Metrics), ничего не записывает; то же для Gauge и Histogram.
+type Counter struct {
+	vec   *prometheus.CounterVec
+	guard *cardinalityGuard
+}
+
+// Inc увеличивает счетчик серии с значениями меток values на 1
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add увеличивает счетчик на v (v >= 0)
+func (c *Counter) Add(v float64, values ...string) {
+	if c.vec == nil {
+		return
+	}
+	if v < 0 {
+		c.guard.logInvalid("negative counter increment", values)
+		return
+	}
+	if values, ok := c.guard.values(values); ok {
+		c.vec.WithLabelValues(values...).Add(v)
+	}
+}
+
+// Gauge - пользовательский gauge
+type Gauge struct {
+	vec   *prometheus.GaugeVec
+	guard *cardinalityGuard
+}
+
+// Set устанавливает значение серии
+func (g *Gauge) Set(v float64, values ...string) {
+	if g.vec == nil {
+		return
+	}
+	if values, ok := g.guard.values(values); ok {
+		g.vec.WithLabelValues(values...).Set(v)
+	}
+}
+
+// Add прибавляет v к значению серии (v может быть отрицательным)
+func (g *Gauge) Add(v float64, values ...string) {
+	if g.vec == nil {
+		return
+	}
+	if values, ok := g.guard.values(values); ok {
+		g.vec.WithLabelValues(values...).Add(v)
+	}
+}
+
+// Histogram - пользовательская гистограмма
+type Histogram struct {
+	vec   *prometheus.HistogramVec
+	guard *cardinalityGuard
+}
+
+// Observe добавляет наблюдение в серию
+func (h *Histogram) Observe(v float64, values ...string) {
+	if h.vec == nil {
+		return
+	}
+	if values, ok := h.guard.values(values); ok {
+		h.vec.WithLabelValues(values...).Observe(v)
+	}
+}
+
+// NewCounter регистрирует счетчик в registry сервера. Имя должно оканчиваться на _total.
+// Если метрики отключены (m == nil), описание проверяется, а счетчик ничего не записывает.
+func (m *Metrics) NewCounter(opts Opts) (*Counter, error) {
+	if !strings.HasSuffix(opts.Name, "_total") {
+		return nil, fmt.Errorf("counter name %q must end with _total", opts.Name)
+	}
+	name, guard, err := m.prepare(opts)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return &Counter{}, nil
+	}
+
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: opts.Help}, opts.Labels)
+	if err := m.registerCustom(name, vec); err != nil {
+		return nil, err
+	}
+	return &Counter{vec: vec, guard: guard}, nil
+}
+
+// NewGauge регистрирует gauge в registry сервера (при m == nil - gauge без записи)
+func (m *Metrics) NewGauge(opts Opts) (*Gauge, error) {
+	name, guard, err := m.prepare(opts)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return &Gauge{}, nil
+	}
+
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: opts.Help}, opts.Labels)
+	if err := m.registerCustom(name, vec); err != nil {
+		return nil, err
+	}
+	return &Gauge{vec: vec, guard: guard}, nil
+}
+
+// NewHistogram регистрирует гистограмму в registry сервера. Native гистограммы
+// включаются вместе со встроенными (WithNativeHistograms). При m == nil
+// возвращается гистограмма без записи.
+func (m *Metrics) NewHistogram(opts HistogramOpts) (*Histogram, error) {
+	name, guard, err := m.prepare(opts.Opts)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := opts.Buckets
+	if buckets == nil {
+		buckets = prometheus.DefBuckets
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return nil, fmt.Errorf("histogram %s: buckets must be in increasing order", name)
+		}
+	}
+	if m == nil {
+		return &Histogram{}, nil
+	}
+
+	vec := prometheus.NewHistogramVec(m.options.histogramOpts(name, opts.Help, buckets), opts.Labels)
+	if err := m.registerCustom(name, vec); err != nil {
+		return nil, err
+	}
+	return &Histogram{vec: vec, guard: guard}, nil
+}
+
+// prepare проверяет описание метрики и возвращает полное имя и ограничитель серий.
+// Для отключенных метрик (m == nil) ограничитель не создается.
+func (m *Metrics) prepare(opts Opts) (string, *cardinalityGuard, error) {
+	if !metricNamePattern.MatchString(opts.Name) {
+		return "", nil, fmt.Errorf("invalid metric name %q", opts.Name)
+	}
+	if opts.Help == "" {
+		return "", nil, fmt.Errorf("metric %s: help is required", opts.Name)
+	}
+
+	name := opts.Name
+	if m != nil && m.options.namespace != "" {
+		name = m.options.namespace + "_" + name
+	}
+
+	seen := make(map[string]bool, len(opts.Labels))
+	for _, label := range opts.Labels {
+		switch {
+		case !labelNamePattern.MatchString(label) || strings.HasPrefix(label, "__"):
+			return "", nil, fmt.Errorf("metric %s: invalid label name %q", name, label)
+		case reservedLabels[label]:
+			return "", nil, fmt.Errorf("metric %s: label name %q is reserved", name, label)
+		case seen[label]:
+			return "", nil, fmt.Errorf("metric %s: duplicate label %q", name, label)
+		}
+		seen[label] = true
+	}
+
+	if opts.MaxSeries < 0 {
+		return "", nil, fmt.Errorf("metric %s: invalid series limit %d", name, opts.MaxSeries)
+	}
+	if m == nil {
+		return name, nil, nil
+	}
+	limit := opts.MaxSeries
+	if limit == 0 {
+		limit = m.options.seriesLimit()
+	}
+	return name, newCardinalityGuard(name, len(opts.Labels), limit, m.overflow), nil
+}
+
+// registerCustom регистрирует collector пользовательской метрики
+func (m *Metrics) registerCustom(name string, c prometheus.Collector) error {
+	if err := m.registry.Register(c); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			return fmt.Errorf("metric %s is already registered", name)
+		}
+		return fmt.Errorf("failed to register metric %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"time"
+
+	"web-server-go-docker/internal/stats"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DurationBuckets - границы http_request_duration_seconds по умолчанию.
+// Включают prometheus.DefBuckets и добавляют интервалы меньше 5ms для быстрых endpoints.
+var DurationBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Параметры native histograms по умолчанию
+const (
+	DefaultNativeBucketFactor = 1.1
+	DefaultNativeMaxBuckets   = 160
+
+	// nativeMinResetDuration - как часто можно сбрасывать native histogram,
+	// если число бакетов превысило лимит
+	nativeMinResetDuration = time.Hour
+)
+
+// Option настраивает метрики при создании
+type Option func(*options)
+
+type options struct {
+	buckets map[string][]float64
+
+	native             bool
+	nativeBucketFactor float64
+	nativeMaxBuckets   uint32
+
+	quantiles *stats.Stats
+
+	// Префикс пользовательских метрик (NewCounter, NewGauge, NewHistogram)
+	namespace string
+	// Предел комбинаций меток встроенных и пользовательских метрик
+	maxSeries int
+
+	statsd *StatsD
+	otlp   *OTLPExporter
+	push   *Pushgateway
+}
+
+// WithBuckets задает границы бакетов гистограммы с именем name
+func WithBuckets(name string, buckets []float64) Option {
+	return func(o *options) {
+		o.buckets[name] = buckets
+	}
+}
+
+// WithNativeHistograms включает native (sparse) гистограммы в дополнение к обычным бакетам.
+// factor - рост ширины бакета (больше 1), maxBuckets - предел числа бакетов на серию.
+// Native гистограммы отдаются только в protobuf формате, текстовый формат не меняется.
+func WithNativeHistograms(factor float64, maxBuckets int) Option {
+	return func(o *options) {
+		o.native = true
+		o.nativeBucketFactor = factor
+		o.nativeMaxBuckets = uint32(maxBuckets)
+	}
+}
+
+// WithNamespace задает префикс имен пользовательских метрик: namespace_name.
+// Встроенные метрики сервера префикс не получают.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithSeriesLimit задает предел комбинаций меток одной метрики: для
+// http_requests_total, http_request_duration_seconds и пользовательских метрик
+// без Opts.MaxSeries. Новые комбинации сверх предела записываются как Overflow.
+func WithSeriesLimit(n int) Option {
+	return func(o *options) {
+		o.maxSeries = n
+	}
+}
+
+// WithQuantiles экспортирует перцентили задержки из скользящих окон st как gauge
+// http_request_duration_window_seconds{window, quantile}. Серии не зависят от маршрутов,
+// поэтому их число постоянно, в отличие от Summary с метками endpoint.
+func WithQuantiles(st *stats.Stats) Option {
+	return func(o *options) {
+		o.quantiles = st
+	}
+}
+
+// WithStatsD дублирует метрики запросов и uptime в StatsD/DogStatsD агент.
+// Metrics.Close закрывает клиент.
+func WithStatsD(client *StatsD) Option {
+	return func(o *options) {
+		o.statsd = client
+	}
+}
+
+// WithOTLP периодически отправляет все метрики registry по OTLP/HTTP.
+// Metrics.Close выполняет последнюю отправку.
+func WithOTLP(exporter *OTLPExporter) Option {
+	return func(o *options) {
+		o.otlp = exporter
+	}
+}
+
+// WithPushgateway отправляет registry в Pushgateway по расписанию и в Metrics.Close
+func WithPushgateway(p *Pushgateway) Option {
+	return func(o *options) {
+		o.push = p
+	}
+}
+
+// seriesLimit возвращает предел серий или DefaultMaxSeries, если он не задан
+func (o *options) seriesLimit() int {
+	if o.maxSeries <= 0 {
+		return DefaultMaxSeries
+	}
+	return o.maxSeries
+}
+
+// histogramOpts возвращает HistogramOpts с бакетами и native настройками из options
+func (o *options) histogramOpts(name, help string, defaultBuckets []float64) prometheus.HistogramOpts {
+	opts := prometheus.HistogramOpts{
+		Name:    name,
+		Help:    help,
+		Buckets: defaultBuckets,
+	}
+	if buckets, ok := o.buckets[name]; ok {
+		opts.Buckets = buckets
+	}
+	if o.native {
+		opts.NativeHistogramBucketFactor = o.nativeBucketFactor
+		opts.NativeHistogramMaxBucketNumber = o.nativeMaxBuckets
+		opts.NativeHistogramMinResetDuration = nativeMinResetDuration
+	}
+	return opts
+}
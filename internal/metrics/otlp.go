@@ -0,0 +1,507 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Temporality - как OTLP получатель интерпретирует значения счетчиков и гистограмм
+const (
+	TemporalityCumulative = "cumulative"
+	TemporalityDelta      = "delta"
+)
+
+// Значения по умолчанию для OTLPOptions
+const (
+	DefaultOTLPInterval = time.Minute
+	DefaultOTLPTimeout  = 10 * time.Second
+)
+
+// Коды AggregationTemporality из metrics.proto
+const (
+	otlpTemporalityDelta      = 1
+	otlpTemporalityCumulative = 2
+)
+
+// otlpScopeName - instrumentation scope экспортируемых метрик
+const otlpScopeName = "web-server-go-docker/internal/metrics"
+
+// OTLPOptions задает получателя и параметры экспорта
+type OTLPOptions struct {
+	// Endpoint - полный URL OTLP/HTTP приемника, например http://collector:4318/v1/metrics
+	Endpoint string
+	// Headers добавляются к каждому запросу (например, ключ доступа)
+	Headers map[string]string
+	// Interval - период отправки
+	Interval time.Duration
+	// Temporality - TemporalityCumulative или TemporalityDelta
+	Temporality string
+	// Timeout ограничивает один запрос
+	Timeout time.Duration
+	// Resource - атрибуты ресурса (service.name, service.version, ...)
+	Resource map[string]string
+}
+
+// OTLPExporter периодически отправляет метрики из Prometheus registry по OTLP/HTTP
+// в JSON кодировке. Pull через /prometheus продолжает работать независимо.
+type OTLPExporter struct {
+	endpoint string
+	headers  map[string]string
+	interval time.Duration
+	delta    bool
+	resource []otlpKeyValue
+	client   *http.Client
+
+	// gatherer и start задаются в New при подключении через WithOTLP
+	gatherer prometheus.Gatherer
+	start    time.Time
+
+	// Для delta: последние отправленные накопленные значения по сериям
+	mu       sync.Mutex
+	previous map[string]otlpCumulative
+	lastSent time.Time
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// otlpCumulative - накопленное значение серии на момент прошлой отправки
+type otlpCumulative struct {
+	value   float64
+	count   uint64
+	buckets []uint64
+}
+
+// NewOTLPExporter проверяет параметры и создает экспортер. Отправка начинается
+// после подключения к Metrics через WithOTLP.
+func NewOTLPExporter(opts OTLPOptions) (*OTLPExporter, error) {
+	u, err := url.Parse(opts.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint: %q", redactURL(opts.Endpoint))
+	}
+
+	temporality := opts.Temporality
+	if temporality == "" {
+		temporality = TemporalityCumulative
+	}
+	if temporality != TemporalityCumulative && temporality != TemporalityDelta {
+		return nil, fmt.Errorf("unknown OTLP temporality: %s", temporality)
+	}
+
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultOTLPInterval
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultOTLPTimeout
+	}
+
+	keys := make([]string, 0, len(opts.Resource))
+	for k := range opts.Resource {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	resource := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		resource = append(resource, stringAttribute(k, opts.Resource[k]))
+	}
+
+	return &OTLPExporter{
+		endpoint: opts.Endpoint,
+		headers:  opts.Headers,
+		interval: interval,
+		delta:    temporality == TemporalityDelta,
+		resource: resource,
+		client:   &http.Client{Timeout: timeout},
+		previous: make(map[string]otlpCumulative),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// attach подключает экспортер к registry и запускает периодическую отправку
+func (e *OTLPExporter) attach(g prometheus.Gatherer, start time.Time) {
+	e.gatherer = g
+	e.start = start
+	e.lastSent = start
+	go e.run()
+}
+
+// run отправляет метрики каждые interval до Close
+func (e *OTLPExporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := e.Export(context.Background()); err != nil {
+				log.Printf("OTLP export failed: %v", err)
+			}
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+// Close останавливает периодическую отправку и отправляет метрики в последний раз
+func (e *OTLPExporter) Close() error {
+	var err error
+	e.once.Do(func() {
+		if e.gatherer == nil {
+			return
+		}
+		close(e.stop)
+		<-e.done
+
+		ctx, cancel := context.WithTimeout(context.Background(), e.client.Timeout)
+		defer cancel()
+		err = e.Export(ctx)
+	})
+	return err
+}
+
+// Export собирает метрики и отправляет их одним запросом
+func (e *OTLPExporter) Export(ctx context.Context) error {
+	families, err := e.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	// Отправки сериализуются, чтобы delta считалась от предыдущей отправки
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	next := make(map[string]otlpCumulative)
+	body, err := json.Marshal(otlpRequest{
+		ResourceMetrics: []otlpResourceMetrics{{
+			Resource: otlpResource{Attributes: e.resource},
+			ScopeMetrics: []otlpScopeMetrics{{
+				Scope:   otlpScope{Name: otlpScopeName},
+				Metrics: e.convert(families, now, next),
+			}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode metrics: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("OTLP receiver returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	// Накопленные значения запоминаются только после успешной отправки,
+	// чтобы после ошибки следующая delta включила и неотправленный интервал
+	for k, v := range next {
+		e.previous[k] = v
+	}
+	e.lastSent = now
+	return nil
+}
+
+// convert переводит семейства Prometheus в метрики OTLP. Для delta накопленные
+// значения серий записываются в next.
+func (e *OTLPExporter) convert(families []*dto.MetricFamily, now time.Time, next map[string]otlpCumulative) []otlpMetric {
+	temporality := otlpTemporalityCumulative
+	start := e.start
+	if e.delta {
+		temporality = otlpTemporalityDelta
+		start = e.lastSent
+	}
+	startNano := strconv.FormatInt(start.UnixNano(), 10)
+	nowNano := strconv.FormatInt(now.UnixNano(), 10)
+
+	var result []otlpMetric
+	for _, f := range families {
+		metric := otlpMetric{
+			Name:        f.GetName(),
+			Description: f.GetHelp(),
+			Unit:        unitFromName(f.GetName()),
+		}
+
+		switch f.GetType() {
+		case dto.MetricType_COUNTER:
+			sum := &otlpSum{AggregationTemporality: temporality, IsMonotonic: true}
+			for _, m := range f.GetMetric() {
+				key := seriesKey(f.GetName(), m)
+				value := m.GetCounter().GetValue()
+				if e.delta {
+					prev := e.previous[key]
+					next[key] = otlpCumulative{value: value}
+					if value >= prev.value {
+						value -= prev.value
+					}
+				}
+				sum.DataPoints = append(sum.DataPoints, otlpNumberPoint{
+					Attributes:        labelAttributes(m),
+					StartTimeUnixNano: startNano,
+					TimeUnixNano:      nowNano,
+					AsDouble:          otlpDouble(value),
+				})
+			}
+			metric.Sum = sum
+
+		case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+			gauge := &otlpGauge{}
+			for _, m := range f.GetMetric() {
+				value := m.GetGauge().GetValue()
+				if f.GetType() == dto.MetricType_UNTYPED {
+					value = m.GetUntyped().GetValue()
+				}
+				gauge.DataPoints = append(gauge.DataPoints, otlpNumberPoint{
+					Attributes:   labelAttributes(m),
+					TimeUnixNano: nowNano,
+					AsDouble:     otlpDouble(value),
+				})
+			}
+			metric.Gauge = gauge
+
+		case dto.MetricType_HISTOGRAM:
+			hist := &otlpHistogram{AggregationTemporality: temporality}
+			for _, m := range f.GetMetric() {
+				hist.DataPoints = append(hist.DataPoints,
+					e.histogramPoint(seriesKey(f.GetName(), m), m, startNano, nowNano, next))
+			}
+			metric.Histogram = hist
+
+		default:
+			// Summary в registry не регистрируются
+			continue
+		}
+
+		result = append(result, metric)
+	}
+	return result
+}
+
+// histogramPoint переводит накопительные бакеты Prometheus в счетчики по интервалам
+func (e *OTLPExporter) histogramPoint(key string, m *dto.Metric, startNano, nowNano string,
+	next map[string]otlpCumulative) otlpHistogramPoint {
+	h := m.GetHistogram()
+
+	bounds := make([]otlpDouble, 0, len(h.GetBucket()))
+	counts := make([]uint64, 0, len(h.GetBucket())+1)
+	var below uint64
+	for _, b := range h.GetBucket() {
+		bounds = append(bounds, otlpDouble(b.GetUpperBound()))
+		counts = append(counts, b.GetCumulativeCount()-below)
+		below = b.GetCumulativeCount()
+	}
+	counts = append(counts, h.GetSampleCount()-below)
+
+	count, sum := h.GetSampleCount(), h.GetSampleSum()
+	if e.delta {
+		prev, ok := e.previous[key]
+		next[key] = otlpCumulative{value: sum, count: count, buckets: append([]uint64(nil), counts...)}
+		// При сбросе (count уменьшился) отправляется текущее значение целиком
+		if ok && count >= prev.count && len(prev.buckets) == len(counts) {
+			count -= prev.count
+			sum -= prev.value
+			for i := range counts {
+				counts[i] -= prev.buckets[i]
+			}
+		}
+	}
+
+	bucketCounts := make([]string, len(counts))
+	for i, c := range counts {
+		bucketCounts[i] = strconv.FormatUint(c, 10)
+	}
+
+	return otlpHistogramPoint{
+		Attributes:        labelAttributes(m),
+		StartTimeUnixNano: startNano,
+		TimeUnixNano:      nowNano,
+		Count:             strconv.FormatUint(count, 10),
+		Sum:               otlpDouble(sum),
+		BucketCounts:      bucketCounts,
+		ExplicitBounds:    bounds,
+	}
+}
+
+// seriesKey - имя метрики и значения меток (в dto они отсортированы по имени)
+func seriesKey(name string, m *dto.Metric) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, l := range m.GetLabel() {
+		b.WriteByte(0)
+		b.WriteString(l.GetName())
+		b.WriteByte('=')
+		b.WriteString(l.GetValue())
+	}
+	return b.String()
+}
+
+// labelAttributes переводит метки Prometheus в атрибуты OTLP
+func labelAttributes(m *dto.Metric) []otlpKeyValue {
+	attrs := make([]otlpKeyValue, 0, len(m.GetLabel()))
+	for _, l := range m.GetLabel() {
+		attrs = append(attrs, stringAttribute(l.GetName(), l.GetValue()))
+	}
+	return attrs
+}
+
+// unitFromName выводит единицу UCUM из суффикса имени по соглашениям Prometheus
+func unitFromName(name string) string {
+	switch {
+	case strings.HasSuffix(name, "_seconds"):
+		return "s"
+	case strings.HasSuffix(name, "_bytes"):
+		return "By"
+	case strings.HasSuffix(name, "_ratio"):
+		return "1"
+	}
+	return ""
+}
+
+func stringAttribute(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: value}}
+}
+
+// Структуры OTLP JSON (opentelemetry-proto, ExportMetricsServiceRequest).
+// 64-битные целые по спецификации кодируются строками.
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Unit        string         `json:"unit,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Gauge       *otlpGauge     `json:"gauge,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpNumberPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberPoint `json:"dataPoints"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+}
+
+type otlpNumberPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	AsDouble          otlpDouble     `json:"asDouble"`
+}
+
+type otlpHistogramPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	Count             string         `json:"count"`
+	Sum               otlpDouble     `json:"sum"`
+	BucketCounts      []string       `json:"bucketCounts"`
+	ExplicitBounds    []otlpDouble   `json:"explicitBounds"`
+}
+
+// otlpDouble - число с плавающей точкой в JSON кодировке protobuf:
+// NaN и бесконечности передаются строками "NaN", "Infinity" и "-Infinity",
+// которые encoding/json для float64 не поддерживает
+type otlpDouble float64
+
+// MarshalJSON кодирует значение числом или строкой для неконечных значений
+func (d otlpDouble) MarshalJSON() ([]byte, error) {
+	v := float64(d)
+	switch {
+	case math.IsNaN(v):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(v, 1):
+		return []byte(`"Infinity"`), nil
+	case math.IsInf(v, -1):
+		return []byte(`"-Infinity"`), nil
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON принимает как числа, так и строковые значения NaN и бесконечностей
+func (d *otlpDouble) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		*d = otlpDouble(v)
+		return nil
+	}
+
+	var v float64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*d = otlpDouble(v)
+	return nil
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
@@ -2,10 +2,18 @@
 package metrics
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
+	"web-server-go-docker/internal/buildinfo"
+	"web-server-go-docker/internal/requestid"
+	"web-server-go-docker/internal/tracing"
+
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -14,12 +22,25 @@ type Metrics struct {
 	RequestsTotal    *prometheus.CounterVec
 	RequestDuration  *prometheus.HistogramVec
 	ServerUptime     *prometheus.GaugeVec
+	BuildInfo        *prometheus.GaugeVec
+	overflow         *prometheus.CounterVec
+	requestsGuard    *cardinalityGuard
+	durationGuard    *cardinalityGuard
 	startTime        time.Time
 	registry         *prometheus.Registry
+	options          *options
+	statsd           *StatsD
+	otlp             *OTLPExporter
+	push             *Pushgateway
 }
 
 // New создает новый экземпляр метрик
-func New() *Metrics {
+func New(opts ...Option) *Metrics {
+	o := &options{buckets: make(map[string][]float64), maxSeries: DefaultMaxSeries}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	// Создаем новый registry для избежания конфликтов в тестах
 	registry := prometheus.NewRegistry()
 	
@@ -32,11 +53,7 @@ func New() *Metrics {
 	)
 	
 	requestDuration := prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "http_request_duration_seconds",
-			Help:    "Duration of HTTP requests.",
-			Buckets: prometheus.DefBuckets,
-		},
+		o.histogramOpts("http_request_duration_seconds", "Duration of HTTP requests.", DurationBuckets),
 		[]string{"method", "endpoint"},
 	)
 	
@@ -48,26 +65,119 @@ func New() *Metrics {
 		nil,
 	)
 
+	buildInfo := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "build_info",
+			Help: "Build information of the running binary. Always 1.",
+		},
+		[]string{"version", "commit", "dirty", "goversion"},
+	)
+
+	cardinalityOverflow := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "metrics_cardinality_overflow_total",
+			Help: "Observations whose label values were recorded as \"other\" because the metric reached its series limit.",
+		},
+		[]string{"metric"},
+	)
+
+	info := buildinfo.Get()
+	buildInfo.WithLabelValues(
+		info.Version,
+		info.ShortCommit(),
+		strconv.FormatBool(info.Dirty),
+		info.GoVersion,
+	).Set(1)
+
 	m := &Metrics{
 		RequestsTotal:   requestsTotal,
 		RequestDuration: requestDuration,
 		ServerUptime:    serverUptime,
+		BuildInfo:       buildInfo,
+		overflow:        cardinalityOverflow,
+		requestsGuard:   newCardinalityGuard("http_requests_total", 3, o.seriesLimit(), cardinalityOverflow),
+		durationGuard:   newCardinalityGuard("http_request_duration_seconds", 2, o.seriesLimit(), cardinalityOverflow),
 		startTime:       time.Now(),
 		registry:        registry,
+		options:         o,
+		statsd:          o.statsd,
+		otlp:            o.otlp,
+		push:            o.push,
 	}
 
 	// Регистрируем метрики в нашем registry
 	registry.MustRegister(requestsTotal)
 	registry.MustRegister(requestDuration)
 	registry.MustRegister(serverUptime)
+	registry.MustRegister(buildInfo)
+	registry.MustRegister(cardinalityOverflow)
+
+	// Метрики рантайма Go (go_*) и процесса (process_*, только на Linux и Windows)
+	registry.MustRegister(collectors.NewGoCollector())
+	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	if o.quantiles != nil {
+		registry.MustRegister(newQuantileCollector(o.quantiles))
+	}
+	if m.otlp != nil {
+		m.otlp.attach(registry, m.startTime)
+	}
+	if m.push != nil {
+		m.push.attach(registry)
+	}
+	if m.statsd != nil {
+		m.statsd.OnFlush(func() {
+			m.statsd.Gauge("server.uptime_seconds", time.Since(m.startTime).Seconds())
+		})
+	}
 
 	return m
 }
 
-// RecordRequest записывает метрики HTTP запроса
-func (m *Metrics) RecordRequest(method, endpoint, status string, duration time.Duration) {
-	m.RequestsTotal.WithLabelValues(method, endpoint, status).Inc()
-	m.RequestDuration.WithLabelValues(method, endpoint).Observe(duration.Seconds())
+// RecordRequest записывает метрики HTTP запроса. Если в ctx есть активный трейс,
+// наблюдение задержки сохраняется как exemplar с trace_id и request_id.
+// Комбинации меток сверх предела серий записываются как Overflow.
+func (m *Metrics) RecordRequest(ctx context.Context, method, endpoint, status string, duration time.Duration) {
+	if values, ok := m.requestsGuard.values([]string{method, endpoint, status}); ok {
+		m.RequestsTotal.WithLabelValues(values...).Inc()
+		if m.statsd != nil {
+			m.statsd.Count("http.requests", 1, "method:"+values[0], "endpoint:"+values[1], "status:"+values[2])
+		}
+	}
+
+	values, ok := m.durationGuard.values([]string{method, endpoint})
+	if !ok {
+		return
+	}
+	if m.statsd != nil {
+		m.statsd.Timing("http.request.duration", duration, "method:"+values[0], "endpoint:"+values[1])
+	}
+	observer := m.RequestDuration.WithLabelValues(values...)
+	if exemplar := exemplarLabels(ctx); exemplar != nil {
+		observer.(prometheus.ExemplarObserver).ObserveWithExemplar(duration.Seconds(), exemplar)
+		return
+	}
+	observer.Observe(duration.Seconds())
+}
+
+// maxExemplarRequestID ограничивает request_id так, чтобы метки exemplar
+// уложились в лимит OpenMetrics (128 символов на имена и значения)
+const maxExemplarRequestID = 64
+
+// exemplarLabels возвращает метки exemplar для запроса или nil, если трейс не активен
+func exemplarLabels(ctx context.Context) prometheus.Labels {
+	sc := tracing.FromContext(ctx)
+	if !sc.Active() {
+		return nil
+	}
+
+	labels := prometheus.Labels{"trace_id": sc.TraceID}
+	if id := requestid.FromContext(ctx); id != "" {
+		if len(id) > maxExemplarRequestID {
+			id = id[:maxExemplarRequestID]
+		}
+		labels["request_id"] = id
+	}
+	return labels
 }
 
 // UpdateUptime обновляет метрику uptime
@@ -75,12 +185,43 @@ func (m *Metrics) UpdateUptime() {
 	m.ServerUptime.WithLabelValues().Set(time.Since(m.startTime).Seconds())
 }
 
+// Close отправляет оставшиеся метрики во внешние приемники и закрывает соединения
+func (m *Metrics) Close() error {
+	var errs []error
+	if m.statsd != nil {
+		errs = append(errs, m.statsd.Close())
+	}
+	if m.otlp != nil {
+		errs = append(errs, m.otlp.Close())
+	}
+	if m.push != nil {
+		errs = append(errs, m.push.Close())
+	}
+	return errors.Join(errs...)
+}
+
 // GetStartTime возвращает время запуска сервера
 func (m *Metrics) GetStartTime() time.Time {
 	return m.startTime
 }
 
-// Handler возвращает HTTP handler для Prometheus метрик
+// Handler возвращает HTTP handler для Prometheus метрик. Формат выбирается по Accept:
+// exemplars отдаются только в OpenMetrics, который Prometheus запрашивает сам.
 func (m *Metrics) Handler() http.Handler {
-	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// Gatherer возвращает registry сервера для чтения всех зарегистрированных метрик
+func (m *Metrics) Gatherer() prometheus.Gatherer {
+	return m.registry
+}
+
+// Register регистрирует дополнительный collector в registry сервера,
+// чтобы его метрики отдавались вместе со стандартными. Если метрики
+// отключены (m == nil), collector не регистрируется.
+func (m *Metrics) Register(c prometheus.Collector) error {
+	if m == nil {
+		return nil
+	}
+	return m.registry.Register(c)
 }
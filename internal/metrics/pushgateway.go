@@ -0,0 +1,202 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// Значения по умолчанию для PushOptions
+const (
+	DefaultPushRetries = 3
+	DefaultPushBackoff = 500 * time.Millisecond
+	DefaultPushTimeout = 10 * time.Second
+
+	// maxPushBackoff ограничивает паузу между повторами
+	maxPushBackoff = 10 * time.Second
+)
+
+// PushOptions задает Pushgateway и расписание отправки
+type PushOptions struct {
+	// URL - адрес Pushgateway, например http://pushgateway:9091
+	URL string
+	// Job - значение метки job группы
+	Job string
+	// Grouping - остальные метки группы (environment, instance). Метрики registry
+	// не должны содержать меток с такими именами.
+	Grouping map[string]string
+	// Interval - период отправки; 0 - только последняя отправка в Close
+	Interval time.Duration
+	// Retries - число повторов после неудачной попытки
+	Retries int
+	// Backoff - пауза перед первым повтором, дальше удваивается
+	Backoff time.Duration
+	// Timeout ограничивает одну попытку
+	Timeout time.Duration
+}
+
+// Pushgateway отправляет registry в Pushgateway методом PUT: группа job/grouping
+// каждый раз заменяется целиком. Метрики, накопленные после последнего scrape,
+// не теряются при остановке контейнера благодаря отправке в Close.
+type Pushgateway struct {
+	// url не содержит userinfo: учетные данные передаются через basic auth,
+	// чтобы пароль не попадал в ошибки и лог
+	url      string
+	user     *url.Userinfo
+	job      string
+	grouping map[string]string
+	interval time.Duration
+	retries  int
+	backoff  time.Duration
+	client   *http.Client
+
+	pusher *push.Pusher
+
+	// ctx отменяется в Close и прерывает периодическую отправку вместе с повторами
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewPushgateway проверяет параметры и создает клиент. Отправка начинается
+// после подключения к Metrics через WithPushgateway.
+func NewPushgateway(opts PushOptions) (*Pushgateway, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid pushgateway URL: %q", redactURL(opts.URL))
+	}
+	if opts.Job == "" {
+		return nil, fmt.Errorf("pushgateway job name is required")
+	}
+	if opts.Retries < 0 {
+		return nil, fmt.Errorf("invalid pushgateway retries: %d", opts.Retries)
+	}
+
+	backoff := opts.Backoff
+	if backoff <= 0 {
+		backoff = DefaultPushBackoff
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultPushTimeout
+	}
+
+	user := u.User
+	u.User = nil
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Pushgateway{
+		url:      u.String(),
+		user:     user,
+		job:      opts.Job,
+		grouping: opts.Grouping,
+		interval: opts.Interval,
+		retries:  opts.Retries,
+		backoff:  backoff,
+		client:   &http.Client{Timeout: timeout},
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// redactURL скрывает пароль в URL для ошибок и лога
+func redactURL(raw string) string {
+	if u, err := url.Parse(raw); err == nil {
+		return u.Redacted()
+	}
+	// Неразобранный URL может содержать userinfo, поэтому он выводится без него
+	if i := strings.LastIndex(raw, "@"); i >= 0 {
+		return "xxxxx" + raw[i:]
+	}
+	return raw
+}
+
+// attach подключает клиент к registry и запускает периодическую отправку
+func (p *Pushgateway) attach(g prometheus.Gatherer) {
+	p.pusher = push.New(p.url, p.job).Gatherer(g).Client(p.client)
+	if p.user != nil {
+		password, _ := p.user.Password()
+		p.pusher = p.pusher.BasicAuth(p.user.Username(), password)
+	}
+	for name, value := range p.grouping {
+		p.pusher = p.pusher.Grouping(name, value)
+	}
+
+	if p.interval <= 0 {
+		close(p.done)
+		return
+	}
+	go p.run()
+}
+
+// run отправляет метрики каждые interval до Close
+func (p *Pushgateway) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.Push(p.ctx); err != nil && p.ctx.Err() == nil {
+				log.Printf("Pushgateway push failed: %v", err)
+			}
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// Push отправляет registry, повторяя неудачные попытки с экспоненциальной паузой
+func (p *Pushgateway) Push(ctx context.Context) error {
+	backoff := p.backoff
+	for attempt := 0; ; attempt++ {
+		err := p.pusher.PushContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if attempt >= p.retries {
+			return fmt.Errorf("push to %s failed after %d attempts: %w", p.url, attempt+1, err)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("push to %s cancelled: %w", p.url, err)
+		}
+		if backoff *= 2; backoff > maxPushBackoff {
+			backoff = maxPushBackoff
+		}
+	}
+}
+
+// Close останавливает периодическую отправку и отправляет метрики в последний раз
+func (p *Pushgateway) Close() error {
+	var err error
+	p.once.Do(func() {
+		p.cancel()
+		if p.pusher == nil {
+			return
+		}
+		<-p.done
+
+		// Последняя отправка вместе с повторами ограничена, чтобы не задерживать остановку
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.retries+1)*p.client.Timeout)
+		defer cancel()
+		err = p.Push(ctx)
+	})
+	return err
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"web-server-go-docker/internal/stats"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// quantileCollector отдает перцентили задержки из stats при каждом scrape
+type quantileCollector struct {
+	stats *stats.Stats
+	desc  *prometheus.Desc
+}
+
+func newQuantileCollector(st *stats.Stats) *quantileCollector {
+	return &quantileCollector{
+		stats: st,
+		desc: prometheus.NewDesc(
+			"http_request_duration_window_seconds",
+			"Latency percentiles of HTTP requests over a sliding window.",
+			[]string{"window", "quantile"},
+			nil,
+		),
+	}
+}
+
+// Describe реализует prometheus.Collector
+func (c *quantileCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect реализует prometheus.Collector
+func (c *quantileCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, w := range stats.DefaultWindows {
+		snap := c.stats.Window(w)
+		window := formatWindow(w)
+		for _, q := range []struct {
+			quantile float64
+			value    time.Duration
+		}{
+			{0.5, snap.Latency.P50},
+			{0.9, snap.Latency.P90},
+			{0.95, snap.Latency.P95},
+			{0.99, snap.Latency.P99},
+		} {
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue,
+				q.value.Seconds(), window, strconv.FormatFloat(q.quantile, 'f', -1, 64))
+		}
+	}
+}
+
+// formatWindow печатает окно без нулевых единиц: 1m, 5m, 90s
+func formatWindow(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return strconv.Itoa(int(d/time.Hour)) + "h"
+	case d%time.Minute == 0:
+		return strconv.Itoa(int(d/time.Minute)) + "m"
+	default:
+		return d.String()
+	}
+}
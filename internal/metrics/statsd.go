@@ -0,0 +1,357 @@
+package metrics
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Форматы протокола StatsD
+const (
+	FlavorStatsD    = "statsd"
+	FlavorDogStatsD = "dogstatsd"
+)
+
+// Значения по умолчанию для StatsDOptions
+const (
+	DefaultStatsDFlushInterval = time.Second
+	DefaultStatsDPacketSize    = 1432 // помещается в Ethernet MTU без фрагментации
+	unixgramPacketSize         = 8192
+)
+
+// maxTimingSamples ограничивает число значений одной серии таймингов между отправками.
+// Лишние значения отбрасываются выборкой, а в пакете передается sample rate.
+const maxTimingSamples = 1000
+
+// StatsDOptions задает адрес и формат отправки
+type StatsDOptions struct {
+	// Address - "udp://host:port", "unixgram:///path/to.sock" или "host:port" (UDP)
+	Address string
+	// Flavor - FlavorStatsD или FlavorDogStatsD. Теги отправляются только в DogStatsD.
+	Flavor string
+	// Prefix добавляется к именам всех метрик ("webserver.")
+	Prefix string
+	// Tags добавляются ко всем метрикам ("env:production")
+	Tags []string
+	// FlushInterval - период отправки агрегированных значений
+	FlushInterval time.Duration
+	// MaxPacketSize - предел размера датаграммы; 0 - по типу сокета
+	MaxPacketSize int
+}
+
+// StatsD агрегирует метрики в памяти и периодически отправляет их на StatsD
+// или DogStatsD агент. Счетчики суммируются, для gauge отправляется последнее
+// значение, тайминги копятся до maxTimingSamples. Строки упаковываются в
+// датаграммы до MaxPacketSize. Методы безопасны для конкурентного вызова.
+type StatsD struct {
+	conn       net.Conn
+	flavor     string
+	prefix     string
+	tags       string
+	packetSize int
+
+	mu       sync.Mutex
+	counters map[statsdKey]int64
+	gauges   map[statsdKey]float64
+	timings  map[statsdKey]*timingSamples
+	onFlush  []func()
+
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+// statsdKey - серия: имя и отформатированные теги
+type statsdKey struct {
+	name string
+	tags string
+}
+
+// timingSamples - выборка таймингов серии с числом всех наблюдений
+type timingSamples struct {
+	values []float64
+	seen   int
+}
+
+// NewStatsD подключается к агенту и запускает периодическую отправку
+func NewStatsD(opts StatsDOptions) (*StatsD, error) {
+	network, addr, err := parseStatsDAddress(opts.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	flavor := opts.Flavor
+	if flavor == "" {
+		flavor = FlavorDogStatsD
+	}
+	if flavor != FlavorStatsD && flavor != FlavorDogStatsD {
+		return nil, fmt.Errorf("unknown statsd flavor: %s", flavor)
+	}
+
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to statsd %s: %w", opts.Address, err)
+	}
+
+	packetSize := opts.MaxPacketSize
+	if packetSize <= 0 {
+		packetSize = DefaultStatsDPacketSize
+		if network == "unixgram" {
+			packetSize = unixgramPacketSize
+		}
+	}
+	interval := opts.FlushInterval
+	if interval <= 0 {
+		interval = DefaultStatsDFlushInterval
+	}
+
+	s := &StatsD{
+		conn:       conn,
+		flavor:     flavor,
+		prefix:     opts.Prefix,
+		tags:       formatTags(opts.Tags),
+		packetSize: packetSize,
+		counters:   make(map[statsdKey]int64),
+		gauges:     make(map[statsdKey]float64),
+		timings:    make(map[statsdKey]*timingSamples),
+		interval:   interval,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go s.run()
+
+	return s, nil
+}
+
+// parseStatsDAddress разбирает адрес агента в сеть и адрес для net.Dial
+func parseStatsDAddress(address string) (string, string, error) {
+	scheme, rest, ok := strings.Cut(address, "://")
+	if !ok {
+		scheme, rest = "udp", address
+	}
+
+	switch scheme {
+	case "udp", "udp4", "udp6":
+		if _, _, err := net.SplitHostPort(rest); err != nil {
+			return "", "", fmt.Errorf("invalid statsd address %s: %w", address, err)
+		}
+	case "unixgram":
+		if rest == "" {
+			return "", "", fmt.Errorf("invalid statsd address %s: empty socket path", address)
+		}
+	default:
+		return "", "", fmt.Errorf("invalid statsd address %s: unsupported scheme %s", address, scheme)
+	}
+	return scheme, rest, nil
+}
+
+// Count увеличивает счетчик name
+func (s *StatsD) Count(name string, value int64, tags ...string) {
+	key := statsdKey{name: name, tags: formatTags(tags)}
+
+	s.mu.Lock()
+	s.counters[key] += value
+	s.mu.Unlock()
+}
+
+// Gauge задает значение gauge name
+func (s *StatsD) Gauge(name string, value float64, tags ...string) {
+	key := statsdKey{name: name, tags: formatTags(tags)}
+
+	s.mu.Lock()
+	s.gauges[key] = value
+	s.mu.Unlock()
+}
+
+// Timing добавляет наблюдение длительности name в миллисекундах
+func (s *StatsD) Timing(name string, d time.Duration, tags ...string) {
+	key := statsdKey{name: name, tags: formatTags(tags)}
+	ms := math.Round(float64(d)/float64(time.Microsecond)) / 1000
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.timings[key]
+	if !ok {
+		t = &timingSamples{}
+		s.timings[key] = t
+	}
+	t.seen++
+	if len(t.values) < maxTimingSamples {
+		t.values = append(t.values, ms)
+		return
+	}
+	// Reservoir sampling: каждое наблюдение попадает в выборку с равной вероятностью
+	if i := rand.Intn(t.seen); i < maxTimingSamples {
+		t.values[i] = ms
+	}
+}
+
+// OnFlush регистрирует функцию, вызываемую перед каждой отправкой (например,
+// чтобы обновить gauge)
+func (s *StatsD) OnFlush(fn func()) {
+	s.mu.Lock()
+	s.onFlush = append(s.onFlush, fn)
+	s.mu.Unlock()
+}
+
+// Flush отправляет накопленные значения и очищает агрегаты
+func (s *StatsD) Flush() error {
+	s.mu.Lock()
+	hooks := s.onFlush
+	s.mu.Unlock()
+	for _, fn := range hooks {
+		fn()
+	}
+
+	s.mu.Lock()
+	counters, gauges, timings := s.counters, s.gauges, s.timings
+	s.counters = make(map[statsdKey]int64)
+	s.gauges = make(map[statsdKey]float64)
+	s.timings = make(map[statsdKey]*timingSamples)
+	s.mu.Unlock()
+
+	var lines []string
+	for key, value := range counters {
+		lines = append(lines, s.line(key, strconv.FormatInt(value, 10), "c", 1))
+	}
+	for key, value := range gauges {
+		lines = append(lines, s.line(key, formatFloat(value), "g", 1))
+	}
+	for key, t := range timings {
+		rate := float64(len(t.values)) / float64(t.seen)
+		for _, v := range t.values {
+			lines = append(lines, s.line(key, formatFloat(v), "ms", rate))
+		}
+	}
+	sort.Strings(lines)
+
+	return s.send(lines)
+}
+
+// line форматирует одну строку протокола: prefix.name:value|type|@rate|#tags
+func (s *StatsD) line(key statsdKey, value, kind string, rate float64) string {
+	var b strings.Builder
+	b.WriteString(s.prefix)
+	b.WriteString(key.name)
+	b.WriteByte(':')
+	b.WriteString(value)
+	b.WriteByte('|')
+	b.WriteString(kind)
+	if rate < 1 {
+		b.WriteString("|@")
+		b.WriteString(strconv.FormatFloat(rate, 'f', 4, 64))
+	}
+
+	if s.flavor == FlavorDogStatsD {
+		tags := s.tags
+		if key.tags != "" {
+			if tags != "" {
+				tags += ","
+			}
+			tags += key.tags
+		}
+		if tags != "" {
+			b.WriteString("|#")
+			b.WriteString(tags)
+		}
+	}
+	return b.String()
+}
+
+// send упаковывает строки в датаграммы не больше packetSize
+func (s *StatsD) send(lines []string) error {
+	var (
+		packet []byte
+		errs   int
+		last   error
+	)
+	write := func() {
+		if len(packet) == 0 {
+			return
+		}
+		if _, err := s.conn.Write(packet); err != nil {
+			errs++
+			last = err
+		}
+		packet = packet[:0]
+	}
+
+	for _, line := range lines {
+		if len(packet) > 0 && len(packet)+1+len(line) > s.packetSize {
+			write()
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+	}
+	write()
+
+	if errs > 0 {
+		return fmt.Errorf("statsd: %d packets not sent: %w", errs, last)
+	}
+	return nil
+}
+
+// run отправляет метрики каждые interval до Close
+func (s *StatsD) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				log.Printf("StatsD flush failed: %v", err)
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Close останавливает периодическую отправку, отправляет остаток и закрывает сокет
+func (s *StatsD) Close() error {
+	var err error
+	s.once.Do(func() {
+		close(s.stop)
+		<-s.done
+		err = s.Flush()
+		if cerr := s.conn.Close(); err == nil {
+			err = cerr
+		}
+	})
+	return err
+}
+
+// formatTags объединяет теги DogStatsD, заменяя символы-разделители протокола
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	clean := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if t = strings.TrimSpace(t); t != "" {
+			clean = append(clean, tagReplacer.Replace(t))
+		}
+	}
+	return strings.Join(clean, ",")
+}
+
+var tagReplacer = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+
+// formatFloat печатает число без экспоненты и лишних нулей
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
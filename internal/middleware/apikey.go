@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"web-server-go-docker/internal/apikey"
+	"web-server-go-docker/internal/handlers"
+	"web-server-go-docker/internal/requestid"
+)
+
+// apiKeyChallenge - WWW-Authenticate для запросов без ключа
+const apiKeyChallenge = `Bearer realm="api"`
+
+// APIKeyMiddleware пропускает запросы с действующим API ключом, у которого есть
+// все scopes маршрута. Ключ передается в заголовке X-API-Key или как
+// Authorization: Bearer <key>; описание ключа доступно через apikey.FromContext.
+type APIKeyMiddleware struct {
+	store  *apikey.Store
+	scopes []string
+}
+
+// NewAPIKeyMiddleware создает APIKeyMiddleware, требующий scopes
+func NewAPIKeyMiddleware(store *apikey.Store, scopes ...string) *APIKeyMiddleware {
+	return &APIKeyMiddleware{store: store, scopes: scopes}
+}
+
+// Name возвращает имя middleware
+func (akm *APIKeyMiddleware) Name() string {
+	return "api_key"
+}
+
+// Handler возвращает middleware handler для проверки API ключа
+func (akm *APIKeyMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, ok := requestAPIKey(r)
+		if !ok {
+			handlers.Unauthorized(w, r, apiKeyChallenge, "API key required")
+			return
+		}
+
+		key, err := akm.store.Authenticate(raw)
+		switch {
+		case errors.Is(err, apikey.ErrExpiredKey):
+			log.Printf("Expired API key %s used for %s %s (request_id=%s)",
+				key.ID, r.Method, r.URL.Path, requestid.FromContext(r.Context()))
+			handlers.Unauthorized(w, r, apiKeyChallenge, "API key expired")
+			return
+		case err != nil:
+			log.Printf("Invalid API key for %s %s (request_id=%s)",
+				r.Method, r.URL.Path, requestid.FromContext(r.Context()))
+			handlers.Unauthorized(w, r, apiKeyChallenge, "invalid API key")
+			return
+		}
+
+		if !key.HasScopes(akm.scopes...) {
+			log.Printf("API key %s lacks scopes %s for %s %s (request_id=%s)",
+				key.ID, strings.Join(akm.scopes, ","), r.Method, r.URL.Path, requestid.FromContext(r.Context()))
+			handlers.Forbidden(w, r, "API key lacks required scopes: "+strings.Join(akm.scopes, ", "))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(apikey.WithContext(r.Context(), key)))
+	})
+}
+
+// requestAPIKey возвращает ключ из X-API-Key или Authorization: Bearer
+func requestAPIKey(r *http.Request) (string, bool) {
+	if key := r.Header.Get(apikey.Header); key != "" {
+		return key, true
+	}
+	if token, ok := bearerToken(r); ok && token != "" {
+		return token, true
+	}
+	return "", false
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+
+	"web-server-go-docker/internal/handlers"
+	"web-server-go-docker/internal/requestid"
+)
+
+// BasicAuthMiddleware пропускает только запросы с верными логином и паролем (RFC 7617)
+type BasicAuthMiddleware struct {
+	realm    string
+	username [sha256.Size]byte
+	password [sha256.Size]byte
+}
+
+// NewBasicAuthMiddleware создает BasicAuthMiddleware с учетными данными в открытом виде
+func NewBasicAuthMiddleware(realm, username, password string) *BasicAuthMiddleware {
+	return &BasicAuthMiddleware{
+		realm:    realm,
+		username: sha256.Sum256([]byte(username)),
+		password: sha256.Sum256([]byte(password)),
+	}
+}
+
+// Name возвращает имя middleware
+func (bam *BasicAuthMiddleware) Name() string {
+	return "basic_auth"
+}
+
+// Handler возвращает middleware handler для проверки basic auth
+func (bam *BasicAuthMiddleware) Handler(next http.Handler) http.Handler {
+	challenge := fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, bam.realm)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok {
+			handlers.Unauthorized(w, r, challenge, "authentication required")
+			return
+		}
+
+		// Сравниваются хеши, чтобы время проверки не зависело от длины и содержимого
+		userHash := sha256.Sum256([]byte(username))
+		passHash := sha256.Sum256([]byte(password))
+		userOK := subtle.ConstantTimeCompare(userHash[:], bam.username[:])
+		passOK := subtle.ConstantTimeCompare(passHash[:], bam.password[:])
+		if userOK&passOK != 1 {
+			log.Printf("Basic auth failed for %s %s (request_id=%s)",
+				r.Method, r.URL.Path, requestid.FromContext(r.Context()))
+			handlers.Unauthorized(w, r, challenge, "invalid credentials")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Condition - условие применения middleware к запросу
+type Condition struct {
+	desc  string
+	match func(r *http.Request) bool
+}
+
+// Match проверяет, подходит ли запрос под условие
+func (c Condition) Match(r *http.Request) bool {
+	return c.match(r)
+}
+
+// String возвращает описание условия для интроспекции
+func (c Condition) String() string {
+	return c.desc
+}
+
+// PathIs совпадает, если путь запроса равен одному из указанных
+func PathIs(paths ...string) Condition {
+	return Condition{
+		desc: "path=" + strings.Join(paths, "|"),
+		match: func(r *http.Request) bool {
+			for _, p := range paths {
+				if r.URL.Path == p {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// PathPrefix совпадает, если путь запроса начинается с одного из префиксов
+func PathPrefix(prefixes ...string) Condition {
+	return Condition{
+		desc: "prefix=" + strings.Join(prefixes, "|"),
+		match: func(r *http.Request) bool {
+			for _, p := range prefixes {
+				if strings.HasPrefix(r.URL.Path, p) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// MethodIs совпадает, если метод запроса равен одному из указанных
+func MethodIs(methods ...string) Condition {
+	return Condition{
+		desc: "method=" + strings.Join(methods, "|"),
+		match: func(r *http.Request) bool {
+			for _, m := range methods {
+				if strings.EqualFold(r.Method, m) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// Any совпадает, если совпадает хотя бы одно из условий
+func Any(conds ...Condition) Condition {
+	descs := make([]string, len(conds))
+	for i, c := range conds {
+		mustCondition("Any", c)
+		descs[i] = c.desc
+	}
+	return Condition{
+		desc: strings.Join(descs, " or "),
+		match: func(r *http.Request) bool {
+			for _, c := range conds {
+				if c.match(r) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// conditionalMiddleware применяет вложенный middleware только при выполнении условия
+type conditionalMiddleware struct {
+	inner  Middleware
+	cond   Condition
+	invert bool
+}
+
+// When применяет m только к запросам, подходящим под условие.
+// Пустое Condition{} - ошибка программиста и приводит к панике при регистрации.
+func When(m Middleware, cond Condition) Middleware {
+	mustCondition("When", cond)
+	return &conditionalMiddleware{inner: m, cond: cond}
+}
+
+// Unless пропускает m для запросов, подходящих под условие
+func Unless(m Middleware, cond Condition) Middleware {
+	mustCondition("Unless", cond)
+	return &conditionalMiddleware{inner: m, cond: cond, invert: true}
+}
+
+// mustCondition паникует на условии, созданном не через PathIs, PathPrefix, MethodIs или Any
+func mustCondition(fn string, cond Condition) {
+	if cond.match == nil {
+		panic("middleware: " + fn + " called with an empty Condition")
+	}
+}
+
+// Name возвращает имя вложенного middleware вместе с условием
+func (cm *conditionalMiddleware) Name() string {
+	op := "when"
+	if cm.invert {
+		op = "unless"
+	}
+	return fmt.Sprintf("%s[%s %s]", Name(cm.inner), op, cm.cond)
+}
+
+// Handler возвращает handler, выбирающий путь выполнения для каждого запроса
+func (cm *conditionalMiddleware) Handler(next http.Handler) http.Handler {
+	wrapped := cm.inner.Handler(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cm.cond.match(r) != cm.invert {
+			wrapped.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
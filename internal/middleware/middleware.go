@@ -1,31 +1,87 @@
 package middleware
 
 import (
+	"fmt"
 	"log"
 	"net/http"
+	"runtime/debug"
 	"strconv"
-	"sync"
+	"strings"
 	"time"
 
+	"web-server-go-docker/internal/apikey"
+	"web-server-go-docker/internal/handlers"
 	"web-server-go-docker/internal/metrics"
+	"web-server-go-docker/internal/requestid"
+	"web-server-go-docker/internal/slo"
+	"web-server-go-docker/internal/stats"
+	"web-server-go-docker/internal/tracing"
 )
 
 // statusResponseWriter оборачивает ResponseWriter для захвата HTTP статуса
 type statusResponseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode  int
+	wroteHeader bool
 }
 
 func (w *statusResponseWriter) WriteHeader(code int) {
-	w.statusCode = code
+	if !w.wroteHeader {
+		w.statusCode = code
+		w.wroteHeader = true
+	}
 	w.ResponseWriter.WriteHeader(code)
 }
 
+func (w *statusResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap позволяет http.ResponseController добраться до исходного ResponseWriter
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // Middleware представляет интерфейс для middleware
 type Middleware interface {
 	Handler(next http.Handler) http.Handler
 }
 
+// Named реализуется middleware, которые сообщают свое имя для интроспекции
+type Named interface {
+	Name() string
+}
+
+// Name возвращает имя middleware или имя его типа, если Named не реализован
+func Name(m Middleware) string {
+	if n, ok := m.(Named); ok {
+		return n.Name()
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", m), "*")
+}
+
+// funcMiddleware адаптирует функцию к интерфейсу Middleware
+type funcMiddleware struct {
+	name string
+	fn   func(http.Handler) http.Handler
+}
+
+// NewFunc создает именованный Middleware из функции-обертки
+func NewFunc(name string, fn func(http.Handler) http.Handler) Middleware {
+	return &funcMiddleware{name: name, fn: fn}
+}
+
+// Name возвращает имя middleware
+func (fm *funcMiddleware) Name() string {
+	return fm.name
+}
+
+// Handler оборачивает next функцией middleware
+func (fm *funcMiddleware) Handler(next http.Handler) http.Handler {
+	return fm.fn(next)
+}
+
 // LoggingMiddleware логирует HTTP запросы и собирает метрики
 type LoggingMiddleware struct {
 	metrics *metrics.Metrics
@@ -38,6 +94,11 @@ func NewLoggingMiddleware(m *metrics.Metrics) *LoggingMiddleware {
 	}
 }
 
+// Name возвращает имя middleware
+func (lm *LoggingMiddleware) Name() string {
+	return "logging"
+}
+
 // Handler возвращает middleware handler для логирования
 func (lm *LoggingMiddleware) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -46,18 +107,27 @@ func (lm *LoggingMiddleware) Handler(next http.Handler) http.Handler {
 		// Оборачиваем ResponseWriter для захвата статуса
 		wrapped := &statusResponseWriter{ResponseWriter: w, statusCode: 200}
 
+		// API ключ проверяется в middleware маршрута, после логирования
+		r = r.WithContext(apikey.Track(r.Context()))
+
 		next.ServeHTTP(wrapped, r)
 
 		duration := time.Since(start)
 
 		// Логируем
-		log.Printf("%s %s %d %s", r.Method, r.URL.Path, wrapped.statusCode, duration)
+		var keyID string
+		if key := apikey.FromContext(r.Context()); key != nil {
+			keyID = " api_key=" + key.ID
+		}
+		log.Printf("%s %s %d %s request_id=%s%s", r.Method, r.URL.Path, wrapped.statusCode, duration,
+			requestid.FromContext(r.Context()), keyID)
 
 		// Собираем метрики если они доступны
 		if lm.metrics != nil {
 			lm.metrics.RecordRequest(
-				r.Method,
-				r.URL.Path,
+				r.Context(),
+				metricMethod(r.Method),
+				metricEndpoint(r),
 				strconv.Itoa(wrapped.statusCode),
 				duration,
 			)
@@ -65,6 +135,125 @@ func (lm *LoggingMiddleware) Handler(next http.Handler) http.Handler {
 	})
 }
 
+// knownMethods - методы, которые попадают в метку method как есть
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true,
+	http.MethodPut: true, http.MethodPatch: true, http.MethodDelete: true,
+	http.MethodConnect: true, http.MethodOptions: true, http.MethodTrace: true,
+}
+
+// metricMethod возвращает метку method: произвольные методы клиента
+// сводятся в metrics.Overflow, чтобы не создавать новые серии
+func metricMethod(method string) string {
+	if knownMethods[method] {
+		return method
+	}
+	return metrics.Overflow
+}
+
+// metricEndpoint возвращает метку endpoint - шаблон маршрута ("/items/{id}"),
+// а не URL.Path, иначе каждый уникальный путь создавал бы новую серию.
+// Запросы без маршрута (404, 405) получают stats.Unmatched.
+func metricEndpoint(r *http.Request) string {
+	route := stats.RouteFromContext(r.Context())
+	if _, path, ok := strings.Cut(route, " "); ok {
+		return path
+	}
+	return route
+}
+
+// TraceContextMiddleware принимает W3C traceparent от клиента или прокси и сохраняет
+// идентификаторы трейса в контексте для exemplars в метриках
+type TraceContextMiddleware struct{}
+
+// NewTraceContextMiddleware создает новый TraceContextMiddleware
+func NewTraceContextMiddleware() *TraceContextMiddleware {
+	return &TraceContextMiddleware{}
+}
+
+// Name возвращает имя middleware
+func (tcm *TraceContextMiddleware) Name() string {
+	return "trace_context"
+}
+
+// Handler возвращает middleware handler для разбора traceparent.
+// Некорректный заголовок игнорируется, как требует спецификация.
+func (tcm *TraceContextMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sc, ok := tracing.Parse(r.Header.Get(tracing.Header)); ok {
+			r = r.WithContext(tracing.WithContext(r.Context(), sc))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequestIDMiddleware присваивает каждому запросу идентификатор.
+// Корректный X-Request-ID от клиента сохраняется, иначе генерируется новый.
+type RequestIDMiddleware struct{}
+
+// NewRequestIDMiddleware создает новый RequestIDMiddleware
+func NewRequestIDMiddleware() *RequestIDMiddleware {
+	return &RequestIDMiddleware{}
+}
+
+// Name возвращает имя middleware
+func (rim *RequestIDMiddleware) Name() string {
+	return "request_id"
+}
+
+// Handler возвращает middleware handler для идентификации запросов
+func (rim *RequestIDMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.WithContext(r.Context(), id)))
+	})
+}
+
+// RecoveryMiddleware перехватывает panic в обработчиках и отвечает 500 problem+json
+type RecoveryMiddleware struct{}
+
+// NewRecoveryMiddleware создает новый RecoveryMiddleware
+func NewRecoveryMiddleware() *RecoveryMiddleware {
+	return &RecoveryMiddleware{}
+}
+
+// Name возвращает имя middleware
+func (rm *RecoveryMiddleware) Name() string {
+	return "recovery"
+}
+
+// Handler возвращает middleware handler для восстановления после panic
+func (rm *RecoveryMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wrapped := &statusResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// http.ErrAbortHandler используется для намеренного обрыва соединения
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			log.Printf("panic serving %s %s (request_id=%s): %v\n%s",
+				r.Method, r.URL.Path, requestid.FromContext(r.Context()), rec, debug.Stack())
+
+			if !wrapped.wroteHeader {
+				handlers.WriteProblem(w, r, handlers.NewProblem(http.StatusInternalServerError, ""))
+			}
+		}()
+
+		next.ServeHTTP(wrapped, r)
+	})
+}
+
 // SecurityMiddleware добавляет security headers
 type SecurityMiddleware struct{}
 
@@ -73,6 +262,11 @@ func NewSecurityMiddleware() *SecurityMiddleware {
 	return &SecurityMiddleware{}
 }
 
+// Name возвращает имя middleware
+func (sm *SecurityMiddleware) Name() string {
+	return "security"
+}
+
 // Handler возвращает middleware handler для security headers
 func (sm *SecurityMiddleware) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -84,28 +278,87 @@ func (sm *SecurityMiddleware) Handler(next http.Handler) http.Handler {
 	})
 }
 
-// RequestCounterMiddleware подсчитывает количество запросов
-type RequestCounterMiddleware struct {
-	counter *int
-	mu      sync.Mutex
+// StatsMiddleware ведет учет запросов в stats.Stats: количество, статусы,
+// запросы в обработке и задержки по маршрутам
+type StatsMiddleware struct {
+	stats *stats.Stats
+}
+
+// NewStatsMiddleware создает новый StatsMiddleware
+func NewStatsMiddleware(s *stats.Stats) *StatsMiddleware {
+	return &StatsMiddleware{
+		stats: s,
+	}
+}
+
+// Name возвращает имя middleware
+func (sm *StatsMiddleware) Name() string {
+	return "stats"
+}
+
+// Handler возвращает middleware handler для учета запросов.
+// Имя маршрута задает обработчик через stats.SetRoute, иначе запрос учитывается как stats.Unmatched.
+func (sm *StatsMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sm.stats.Begin()
+
+		wrapped := &statusResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		r = r.WithContext(stats.WithRoute(r.Context()))
+
+		defer func() {
+			// Panic, прошедший мимо recovery, все равно должен уменьшить счетчик in-flight
+			status := wrapped.statusCode
+			if rec := recover(); rec != nil {
+				status = http.StatusInternalServerError
+				defer panic(rec)
+			}
+			route, duration := stats.RouteFromContext(r.Context()), time.Since(start)
+			sm.stats.End(route, status, duration)
+			if status >= http.StatusInternalServerError {
+				sm.stats.RecordError(stats.ErrorEvent{
+					Time:      start,
+					Method:    r.Method,
+					Path:      r.URL.Path,
+					Route:     route,
+					Status:    status,
+					RequestID: requestid.FromContext(r.Context()),
+					Duration:  duration,
+				})
+			}
+		}()
+
+		next.ServeHTTP(wrapped, r)
+	})
+}
+
+// SLOMiddleware учитывает запросы в счетчиках SLI. Должен стоять после
+// StatsMiddleware: имя маршрута берется из контекста, подготовленного им.
+type SLOMiddleware struct {
+	tracker *slo.Tracker
 }
 
-// NewRequestCounterMiddleware создает новый RequestCounterMiddleware
-func NewRequestCounterMiddleware(counter *int) *RequestCounterMiddleware {
-	return &RequestCounterMiddleware{
-		counter: counter,
+// NewSLOMiddleware создает новый SLOMiddleware
+func NewSLOMiddleware(t *slo.Tracker) *SLOMiddleware {
+	return &SLOMiddleware{
+		tracker: t,
 	}
 }
 
-// Handler возвращает middleware handler для подсчета запросов
-func (rcm *RequestCounterMiddleware) Handler(next http.Handler) http.Handler {
+// Name возвращает имя middleware
+func (sm *SLOMiddleware) Name() string {
+	return "slo"
+}
+
+// Handler возвращает middleware handler для учета событий SLI
+func (sm *SLOMiddleware) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if rcm.counter != nil {
-			rcm.mu.Lock()
-			(*rcm.counter)++
-			rcm.mu.Unlock()
-		}
-		next.ServeHTTP(w, r)
+		start := time.Now()
+		wrapped := &statusResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(wrapped, r)
+
+		sm.tracker.Observe(stats.RouteFromContext(r.Context()), wrapped.statusCode, time.Since(start))
 	})
 }
 
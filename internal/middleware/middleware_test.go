@@ -1,40 +1,60 @@
 package middleware
 
 import (
+	"log"
 	"net/http"
 	"net/http/httptest"
-	"strconv"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 
+	"web-server-go-docker/internal/apikey"
+	"web-server-go-docker/internal/handlers"
 	"web-server-go-docker/internal/metrics"
+	"web-server-go-docker/internal/requestid"
+	"web-server-go-docker/internal/slo"
+	"web-server-go-docker/internal/stats"
+	"web-server-go-docker/internal/tracing"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
-func TestRequestCounterMiddleware(t *testing.T) {
-	counter := 0
-	rcm := NewRequestCounterMiddleware(&counter)
+func TestStatsMiddleware(t *testing.T) {
+	st := stats.New()
+	sm := NewStatsMiddleware(st)
 
-	handler := rcm.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
+	handler := sm.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/known" {
+			stats.SetRoute(r.Context(), "GET /known")
+		}
+		w.WriteHeader(http.StatusAccepted)
 	}))
 
-	req := httptest.NewRequest(http.MethodGet, "/counter", nil)
-	rr := httptest.NewRecorder()
-
-	handler.ServeHTTP(rr, req)
+	for _, path := range []string{"/known", "/known", "/other"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
 
-	if counter != 1 {
-		t.Errorf("expected counter to be 1, got %d", counter)
+	snap := st.Snapshot()
+	if snap.Total != 3 || snap.InFlight != 0 {
+		t.Errorf("expected 3 requests and 0 in flight, got %d and %d", snap.Total, snap.InFlight)
+	}
+	if snap.Statuses["202"] != 3 {
+		t.Errorf("expected status 202 to be counted, got %v", snap.Statuses)
+	}
+	if len(snap.Routes) != 2 || snap.Routes[0].Route != "GET /known" || snap.Routes[0].Requests != 2 ||
+		snap.Routes[1].Route != stats.Unmatched {
+		t.Errorf("unexpected routes: %+v", snap.Routes)
 	}
 }
 
-func TestRequestCounterMiddleware_Concurrent(t *testing.T) {
-	counter := 0
-	rcm := NewRequestCounterMiddleware(&counter)
+func TestStatsMiddleware_Concurrent(t *testing.T) {
+	st := stats.New()
+	sm := NewStatsMiddleware(st)
 
-	handler := rcm.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := sm.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -48,35 +68,484 @@ func TestRequestCounterMiddleware_Concurrent(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/counter", nil)
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
+			st.Snapshot()
 		}()
 	}
 
 	wg.Wait()
 
-	if counter != workers {
-		t.Errorf("expected counter to be %d, got %d", workers, counter)
+	if total := st.Total(); total != workers {
+		t.Errorf("expected counter to be %d, got %d", workers, total)
+	}
+}
+
+func TestStatsMiddleware_Panic(t *testing.T) {
+	st := stats.New()
+	handler := NewStatsMiddleware(st).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic to be propagated")
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+
+	snap := st.Snapshot()
+	if snap.InFlight != 0 || snap.Errors != 1 {
+		t.Errorf("expected panic to be counted as finished error, got in_flight=%d errors=%d", snap.InFlight, snap.Errors)
 	}
 }
 
 func TestLoggingMiddlewareRecordsMetrics(t *testing.T) {
 	m := metrics.New()
-	lm := NewLoggingMiddleware(m)
+	chain := Chain(NewStatsMiddleware(stats.New()), NewLoggingMiddleware(m))
 
-	handler := lm.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		stats.SetRoute(r.Context(), "GET /items/{id}")
 		w.WriteHeader(http.StatusOK)
-	}))
+	})
+	handler := chain(mux)
 
-	req := httptest.NewRequest(http.MethodGet, "/log", nil)
-	rr := httptest.NewRecorder()
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		labels []string
+	}{
+		{"route template", http.MethodGet, "/items/1", []string{http.MethodGet, "/items/{id}", "200"}},
+		{"same route", http.MethodGet, "/items/2", []string{http.MethodGet, "/items/{id}", "200"}},
+		{"unknown path", http.MethodGet, "/wp-login.php", []string{http.MethodGet, stats.Unmatched, "404"}},
+		{"unknown method", "BREW", "/items/1", []string{metrics.Overflow, stats.Unmatched, "405"}},
+	}
 
-	handler.ServeHTTP(rr, req)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
 
-	counter := testutil.ToFloat64(m.RequestsTotal.WithLabelValues(http.MethodGet, "/log", strconv.Itoa(http.StatusOK)))
-	if counter != 1 {
-		t.Errorf("expected metric counter 1, got %v", counter)
+			if counter := testutil.ToFloat64(m.RequestsTotal.WithLabelValues(tt.labels...)); counter == 0 {
+				t.Errorf("expected request recorded with labels %v", tt.labels)
+			}
+		})
 	}
 
+	if n := testutil.CollectAndCount(m.RequestsTotal); n != 3 {
+		t.Errorf("expected 3 request series, got %d", n)
+	}
 	if n := testutil.CollectAndCount(m.RequestDuration); n == 0 {
 		t.Error("expected request duration metric to be recorded")
 	}
 }
+
+func TestRequestIDMiddleware(t *testing.T) {
+	rim := NewRequestIDMiddleware()
+
+	var seen string
+	handler := rim.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestid.FromContext(r.Context())
+	}))
+
+	t.Run("generates id", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if seen == "" {
+			t.Fatal("expected request id in context")
+		}
+		if rr.Header().Get(requestid.Header) != seen {
+			t.Errorf("expected response header %q, got %q", seen, rr.Header().Get(requestid.Header))
+		}
+	})
+
+	t.Run("keeps valid client id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(requestid.Header, "client-id-1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if seen != "client-id-1" {
+			t.Errorf("expected client id to be kept, got %q", seen)
+		}
+	})
+
+	t.Run("replaces invalid client id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(requestid.Header, "bad id\nwith newline")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if seen == "bad id\nwith newline" {
+			t.Error("expected invalid client id to be replaced")
+		}
+	})
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	rm := NewRecoveryMiddleware()
+
+	handler := rm.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != handlers.ProblemContentType {
+		t.Errorf("expected problem content type, got %q", ct)
+	}
+}
+
+func TestConditionalMiddleware(t *testing.T) {
+	marker := NewFunc("marker", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Marker", "1")
+			next.ServeHTTP(w, r)
+		})
+	})
+	final := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name     string
+		mw       Middleware
+		method   string
+		path     string
+		expected bool
+	}{
+		{"unless path skips", Unless(marker, PathIs("/health")), http.MethodGet, "/health", false},
+		{"unless path applies elsewhere", Unless(marker, PathIs("/health")), http.MethodGet, "/api", true},
+		{"when prefix applies", When(marker, PathPrefix("/api/")), http.MethodGet, "/api/items", true},
+		{"when prefix skips", When(marker, PathPrefix("/api/")), http.MethodGet, "/health", false},
+		{"unless method", Unless(marker, MethodIs(http.MethodOptions)), http.MethodOptions, "/", false},
+		{"when any", When(marker, Any(PathIs("/a"), MethodIs(http.MethodPost))), http.MethodPost, "/b", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tt.mw.Handler(final).ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if applied := rr.Header().Get("X-Marker") == "1"; applied != tt.expected {
+				t.Errorf("expected applied=%v, got %v", tt.expected, applied)
+			}
+		})
+	}
+
+	if name := Name(Unless(marker, PathIs("/health", "/ready"))); name != "marker[unless path=/health|/ready]" {
+		t.Errorf("unexpected conditional middleware name %q", name)
+	}
+
+	// Пустое условие отклоняется при регистрации, а не паникует на каждом запросе
+	for name, register := range map[string]func(){
+		"when":   func() { When(marker, Condition{}) },
+		"unless": func() { Unless(marker, Condition{}) },
+		"any":    func() { Any(PathIs("/a"), Condition{}) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic on empty Condition", name)
+				}
+			}()
+			register()
+		}()
+	}
+}
+
+func TestBasicAuthMiddleware(t *testing.T) {
+	bam := NewBasicAuthMiddleware("admin", "admin", "s3cret")
+	handler := bam.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		setAuth  bool
+		status   int
+	}{
+		{"no credentials", "", "", false, http.StatusUnauthorized},
+		{"wrong password", "admin", "guess", true, http.StatusUnauthorized},
+		{"wrong username", "root", "s3cret", true, http.StatusUnauthorized},
+		{"valid credentials", "admin", "s3cret", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+			if tt.setAuth {
+				req.SetBasicAuth(tt.username, tt.password)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rr.Code)
+			}
+			if tt.status == http.StatusUnauthorized {
+				if got := rr.Header().Get("WWW-Authenticate"); got != `Basic realm="admin", charset="UTF-8"` {
+					t.Errorf("unexpected challenge %q", got)
+				}
+				if ct := rr.Header().Get("Content-Type"); ct != handlers.ProblemContentType {
+					t.Errorf("expected problem+json, got %s", ct)
+				}
+			}
+		})
+	}
+}
+
+func TestScrapeAuthMiddleware(t *testing.T) {
+	// bcrypt хеш пароля scrape-secret (cost 4)
+	const hash = "$2a$04$uDXFZaYveoFJoo264FmFue5JwZPbwWmeSKaLkQQSlam7F/Svu2666"
+	const token = "0123456789abcdef"
+
+	sam, err := NewScrapeAuthMiddleware(ScrapeAuthOptions{
+		Users:           map[string]string{"prometheus": hash},
+		BearerToken:     token,
+		AllowedNetworks: []string{"10.0.0.0/8", "::1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := sam.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		auth       func(r *http.Request)
+		status     int
+	}{
+		{"no credentials", "10.1.2.3:4000", func(r *http.Request) {}, http.StatusUnauthorized},
+		{"valid basic auth", "10.1.2.3:4000", func(r *http.Request) { r.SetBasicAuth("prometheus", "scrape-secret") }, http.StatusOK},
+		{"cached basic auth", "10.1.2.3:4000", func(r *http.Request) { r.SetBasicAuth("prometheus", "scrape-secret") }, http.StatusOK},
+		{"wrong password", "10.1.2.3:4000", func(r *http.Request) { r.SetBasicAuth("prometheus", "guess") }, http.StatusUnauthorized},
+		{"unknown user", "10.1.2.3:4000", func(r *http.Request) { r.SetBasicAuth("admin", "scrape-secret") }, http.StatusUnauthorized},
+		{"valid bearer token", "[::1]:4000", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }, http.StatusOK},
+		{"wrong bearer token", "[::1]:4000", func(r *http.Request) { r.Header.Set("Authorization", "Bearer guess") }, http.StatusUnauthorized},
+		{"address not allowed", "192.0.2.1:4000", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }, http.StatusForbidden},
+		{"forwarded address is ignored", "192.0.2.1:4000", func(r *http.Request) {
+			r.Header.Set("X-Forwarded-For", "10.0.0.1")
+			r.Header.Set("Authorization", "Bearer "+token)
+		}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/prometheus", nil)
+			req.RemoteAddr = tt.remoteAddr
+			tt.auth(req)
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rr.Code)
+			}
+			if tt.status == http.StatusUnauthorized {
+				want := `Basic realm="metrics", charset="UTF-8", Bearer realm="metrics"`
+				if got := rr.Header().Get("WWW-Authenticate"); got != want {
+					t.Errorf("unexpected challenge %q", got)
+				}
+			}
+		})
+	}
+}
+
+func TestNewScrapeAuthMiddleware_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ScrapeAuthOptions
+	}{
+		{"plain password", ScrapeAuthOptions{Users: map[string]string{"prometheus": "secret"}}},
+		{"invalid network", ScrapeAuthOptions{AllowedNetworks: []string{"10.0.0.0/33"}}},
+		{"invalid address", ScrapeAuthOptions{AllowedNetworks: []string{"localhost"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewScrapeAuthMiddleware(tt.opts); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	keys := "keys:\n" +
+		"  - {id: writer, owner: billing, hash: " + apikey.Hash("writer-key") + ", scopes: [orders:read, orders:write]}\n" +
+		"  - {id: reader, hash: " + apikey.Hash("reader-key") + ", scopes: [orders:read]}\n" +
+		"  - {id: admin, hash: " + apikey.Hash("admin-key") + ", scopes: ['*']}\n" +
+		"  - {id: old, hash: " + apikey.Hash("old-key") + ", scopes: ['*'], expires_at: 2020-01-01T00:00:00Z}\n"
+	if err := os.WriteFile(path, []byte(keys), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := apikey.NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var seen string
+	handler := NewAPIKeyMiddleware(store, "orders:write").Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = apikey.FromContext(r.Context()).ID
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+		wantID string
+	}{
+		{"no key", "", "", http.StatusUnauthorized, ""},
+		{"header key", apikey.Header, "writer-key", http.StatusOK, "writer"},
+		{"bearer key", "Authorization", "Bearer writer-key", http.StatusOK, "writer"},
+		{"wildcard scope", apikey.Header, "admin-key", http.StatusOK, "admin"},
+		{"missing scope", apikey.Header, "reader-key", http.StatusForbidden, ""},
+		{"unknown key", apikey.Header, "guess", http.StatusUnauthorized, ""},
+		{"expired key", "Authorization", "Bearer old-key", http.StatusUnauthorized, ""},
+		{"basic auth is not a key", "Authorization", "Basic d3JpdGVyLWtleTo=", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = ""
+			req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rr.Code)
+			}
+			if seen != tt.wantID {
+				t.Errorf("expected key %q in context, got %q", tt.wantID, seen)
+			}
+			if tt.status == http.StatusUnauthorized && rr.Header().Get("WWW-Authenticate") != `Bearer realm="api"` {
+				t.Errorf("unexpected challenge %q", rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestLoggingMiddlewareLogsAPIKey(t *testing.T) {
+	var buf strings.Builder
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	key := &apikey.Key{ID: "writer"}
+	handler := NewLoggingMiddleware(nil).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Так ключ устанавливает APIKeyMiddleware маршрута
+		apikey.WithContext(r.Context(), key)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	if !strings.Contains(buf.String(), "api_key=writer") {
+		t.Errorf("expected api_key in log line, got %q", buf.String())
+	}
+}
+
+func TestStatsMiddleware_RecordsRecentErrors(t *testing.T) {
+	st := stats.New()
+	handler := NewRequestIDMiddleware().Handler(NewStatsMiddleware(st).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})))
+
+	for _, path := range []string{"/fail", "/missing"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	recent := st.RecentErrors()
+	if len(recent) != 1 {
+		t.Fatalf("expected only 5xx to be recorded, got %+v", recent)
+	}
+	if recent[0].Path != "/fail" || recent[0].Status != http.StatusBadGateway || recent[0].RequestID == "" {
+		t.Errorf("unexpected error event: %+v", recent[0])
+	}
+}
+
+func TestTraceContextMiddleware(t *testing.T) {
+	tcm := NewTraceContextMiddleware()
+
+	var seen tracing.SpanContext
+	handler := tcm.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = tracing.FromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name        string
+		traceparent string
+		active      bool
+	}{
+		{"sampled trace", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"unsampled trace", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", false},
+		{"malformed header", "00-xyz-01", false},
+		{"no header", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = tracing.SpanContext{}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.traceparent != "" {
+				req.Header.Set(tracing.Header, tt.traceparent)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if seen.Active() != tt.active {
+				t.Errorf("expected active=%v, got %+v", tt.active, seen)
+			}
+		})
+	}
+}
+
+func TestSLOMiddleware(t *testing.T) {
+	tracker := slo.NewTracker(&slo.Spec{Objectives: []slo.Objective{
+		{Name: "known", Route: "GET /known", Type: slo.Availability, Target: 0.99},
+	}})
+	chain := Chain(NewStatsMiddleware(stats.New()), NewSLOMiddleware(tracker))
+
+	handler := chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/other" {
+			stats.SetRoute(r.Context(), "GET /known")
+		}
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+
+	for _, target := range []string{"/known", "/known?fail=1", "/other"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(tracker)
+	expected := `
+# HELP slo_events_total Total number of events counted by the SLI.
+# TYPE slo_events_total counter
+slo_events_total{slo="known"} 2
+# HELP slo_good_events_total Number of good events counted by the SLI.
+# TYPE slo_good_events_total counter
+slo_good_events_total{slo="known"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "slo_events_total", "slo_good_events_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"web-server-go-docker/internal/handlers"
+	"web-server-go-docker/internal/requestid"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyHash сравнивается с паролем неизвестного пользователя, чтобы время ответа
+// не выдавало, существует ли логин (bcrypt, cost 10)
+var dummyHash = []byte("$2a$10$yzDHnCGgVgy4s.3AssXnpO6RhYF1a4LKhJ1OZ4ajrG4g2U2Km2TKy")
+
+// ScrapeAuthOptions задает доступ к пути метрик. Пустое поле отключает проверку.
+type ScrapeAuthOptions struct {
+	// Users - логины и bcrypt хеши паролей, как basic_auth_users в web-config.yml
+	// exporter'ов; в scrape config Prometheus - basic_auth
+	Users map[string]string
+	// BearerToken - токен из authorization (type: Bearer) в scrape config Prometheus
+	BearerToken string
+	// AllowedNetworks - адреса и подсети (CIDR), с которых разрешен доступ
+	AllowedNetworks []string
+}
+
+// ScrapeAuthMiddleware защищает путь метрик: проверяет адрес клиента по списку
+// подсетей, затем basic auth или bearer token. Если заданы и пользователи, и токен,
+// достаточно любого из них.
+type ScrapeAuthMiddleware struct {
+	users     map[string][]byte
+	token     [sha256.Size]byte
+	hasToken  bool
+	networks  []netip.Prefix
+	challenge string
+
+	// verified - хеши уже проверенных пар логин/пароль: bcrypt намеренно медленный,
+	// а Prometheus присылает одни и те же учетные данные каждый scrape
+	mu       sync.Mutex
+	verified map[[sha256.Size]byte]struct{}
+}
+
+// NewScrapeAuthMiddleware создает ScrapeAuthMiddleware. Возвращает ошибку для
+// неверных bcrypt хешей и адресов.
+func NewScrapeAuthMiddleware(opts ScrapeAuthOptions) (*ScrapeAuthMiddleware, error) {
+	sam := &ScrapeAuthMiddleware{
+		users:    make(map[string][]byte, len(opts.Users)),
+		verified: make(map[[sha256.Size]byte]struct{}),
+	}
+
+	for user, hash := range opts.Users {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, fmt.Errorf("invalid bcrypt hash for user %q: %w", user, err)
+		}
+		sam.users[user] = []byte(hash)
+	}
+	if opts.BearerToken != "" {
+		sam.token = sha256.Sum256([]byte(opts.BearerToken))
+		sam.hasToken = true
+	}
+	for _, network := range opts.AllowedNetworks {
+		prefix, err := parseNetwork(network)
+		if err != nil {
+			return nil, err
+		}
+		sam.networks = append(sam.networks, prefix)
+	}
+
+	var challenges []string
+	if len(sam.users) > 0 {
+		challenges = append(challenges, `Basic realm="metrics", charset="UTF-8"`)
+	}
+	if sam.hasToken {
+		challenges = append(challenges, `Bearer realm="metrics"`)
+	}
+	sam.challenge = strings.Join(challenges, ", ")
+
+	return sam, nil
+}
+
+// parseNetwork разбирает адрес (10.0.0.1) или подсеть (10.0.0.0/8)
+func parseNetwork(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid network %q: %w", s, err)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid network %q: %w", s, err)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Name возвращает имя middleware
+func (sam *ScrapeAuthMiddleware) Name() string {
+	return "scrape_auth"
+}
+
+// Handler возвращает middleware handler для проверки доступа к метрикам
+func (sam *ScrapeAuthMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !sam.allowedAddr(r) {
+			log.Printf("Metrics scrape from %s rejected: address not allowed (request_id=%s)",
+				r.RemoteAddr, requestid.FromContext(r.Context()))
+			handlers.Forbidden(w, r, "address not allowed")
+			return
+		}
+		if sam.challenge == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			handlers.Unauthorized(w, r, sam.challenge, "authentication required")
+			return
+		}
+		if !sam.authorized(r) {
+			log.Printf("Metrics scrape from %s rejected: invalid credentials (request_id=%s)",
+				r.RemoteAddr, requestid.FromContext(r.Context()))
+			handlers.Unauthorized(w, r, sam.challenge, "invalid credentials")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedAddr проверяет адрес клиента. Используется адрес соединения, а не
+// X-Forwarded-For: заголовок может подставить сам клиент. Доступ через unix socket
+// ограничивается правами на файл сокета.
+func (sam *ScrapeAuthMiddleware) allowedAddr(r *http.Request) bool {
+	if len(sam.networks) == 0 {
+		return true
+	}
+	if _, ok := r.Context().Value(http.LocalAddrContextKey).(*net.UnixAddr); ok {
+		return true
+	}
+
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+	for _, prefix := range sam.networks {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorized проверяет bearer token или basic auth запроса
+func (sam *ScrapeAuthMiddleware) authorized(r *http.Request) bool {
+	if token, ok := bearerToken(r); ok {
+		if !sam.hasToken {
+			return false
+		}
+		hash := sha256.Sum256([]byte(token))
+		return subtle.ConstantTimeCompare(hash[:], sam.token[:]) == 1
+	}
+
+	username, password, ok := r.BasicAuth()
+	if !ok || len(sam.users) == 0 {
+		return false
+	}
+
+	key := sha256.Sum256([]byte(username + "\x00" + password))
+	sam.mu.Lock()
+	_, cached := sam.verified[key]
+	sam.mu.Unlock()
+	if cached {
+		return true
+	}
+
+	hash, known := sam.users[username]
+	if !known {
+		hash = dummyHash
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil || !known {
+		return false
+	}
+
+	sam.mu.Lock()
+	sam.verified[key] = struct{}{}
+	sam.mu.Unlock()
+	return true
+}
+
+// bearerToken возвращает токен из заголовка Authorization: Bearer <token>
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
@@ -2,9 +2,10 @@ package models
 
 // HealthResponse представляет ответ health check endpoint
 type HealthResponse struct {
-	Status    string `json:"status"`
-	Timestamp string `json:"timestamp"`
-	Version   string `json:"version"`
+	Status    string            `json:"status"`
+	Timestamp string            `json:"timestamp"`
+	Version   string            `json:"version"`
+	Checks    map[string]string `json:"checks,omitempty"`
 }
 
 // InfoResponse представляет ответ info endpoint
@@ -16,7 +17,111 @@ type InfoResponse struct {
 
 // MetricsResponse представляет ответ metrics endpoint
 type MetricsResponse struct {
-	RequestCount int    `json:"request_count"`
-	Uptime       string `json:"uptime"`
-	StartTime    string `json:"start_time"`
+	RequestCount int               `json:"request_count"`
+	Uptime       string            `json:"uptime"`
+	StartTime    string            `json:"start_time"`
+	InFlight     int64             `json:"in_flight" doc:"Requests being processed right now"`
+	ErrorCount   uint64            `json:"error_count" doc:"Responses with 5xx status"`
+	ErrorRate    float64           `json:"error_rate" doc:"Share of 5xx responses since start (0..1)"`
+	StatusCodes  map[string]uint64 `json:"status_codes"`
+	Routes       []RouteMetrics    `json:"routes"`
+	Windows      []WindowMetrics   `json:"windows"`
+}
+
+// RecentErrorsResponse - последние запросы с ответом 5xx (только для администратора:
+// содержит пути и идентификаторы запросов)
+type RecentErrorsResponse struct {
+	Errors []ErrorInfo `json:"errors" doc:"Last requests answered with 5xx, newest first"`
+}
+
+// ErrorInfo описывает запрос, завершившийся ошибкой 5xx
+type ErrorInfo struct {
+	Timestamp  string  `json:"timestamp"`
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Route      string  `json:"route"`
+	Status     int     `json:"status"`
+	RequestID  string  `json:"request_id,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// RouteMetrics - статистика запросов одного маршрута
+type RouteMetrics struct {
+	Route        string  `json:"route" doc:"Route pattern, e.g. \"GET /health\", or \"unmatched\""`
+	Requests     uint64  `json:"requests"`
+	Errors       uint64  `json:"errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	MaxLatencyMs float64 `json:"max_latency_ms"`
+}
+
+// WindowMetrics - статистика за скользящее окно
+type WindowMetrics struct {
+	Window      string             `json:"window"`
+	Requests    uint64             `json:"requests"`
+	Errors      uint64             `json:"errors"`
+	RequestRate float64            `json:"request_rate" doc:"Requests per second"`
+	ErrorRate   float64            `json:"error_rate"`
+	Latency     LatencyPercentiles `json:"latency"`
+}
+
+// LatencyPercentiles - перцентили задержки в миллисекундах
+type LatencyPercentiles struct {
+	P50 float64 `json:"p50_ms"`
+	P90 float64 `json:"p90_ms"`
+	P95 float64 `json:"p95_ms"`
+	P99 float64 `json:"p99_ms"`
+}
+
+// MetricsHistoryResponse представляет ответ metrics history endpoint
+type MetricsHistoryResponse struct {
+	Window     string         `json:"window"`
+	Resolution string         `json:"resolution"`
+	Points     []HistoryPoint `json:"points"`
+}
+
+// HistoryPoint - статистика сервера за один интервал истории
+type HistoryPoint struct {
+	Timestamp      string             `json:"timestamp" doc:"End of the interval (RFC 3339)"`
+	Requests       uint64             `json:"requests"`
+	Errors         uint64             `json:"errors"`
+	RequestRate    float64            `json:"request_rate" doc:"Requests per second"`
+	ErrorRate      float64            `json:"error_rate"`
+	Latency        LatencyPercentiles `json:"latency"`
+	HeapAllocBytes uint64             `json:"heap_alloc_bytes"`
+	Goroutines     int                `json:"goroutines"`
+}
+
+// DependencyInfo описывает модуль, вошедший в сборку
+type DependencyInfo struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`
+	Replace string `json:"replace,omitempty"`
+}
+
+// VersionResponse представляет ответ version endpoint
+type VersionResponse struct {
+	Version      string           `json:"version"`
+	AppVersion   string           `json:"app_version"`
+	Commit       string           `json:"commit"`
+	Dirty        bool             `json:"dirty"`
+	BuildTime    string           `json:"build_time,omitempty"`
+	CommitTime   string           `json:"commit_time,omitempty"`
+	GoVersion    string           `json:"go_version"`
+	Module       string           `json:"module"`
+	Dependencies []DependencyInfo `json:"dependencies"`
+}
+
+// RouteResponse описывает маршрут и его эффективную цепочку middleware
+type RouteResponse struct {
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Description string   `json:"description,omitempty"`
+	Middleware  []string `json:"middleware"`
+}
+
+// RoutesResponse представляет ответ debug routes endpoint
+type RoutesResponse struct {
+	Routes []RouteResponse `json:"routes"`
 }
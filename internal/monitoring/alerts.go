@@ -0,0 +1,171 @@
+package monitoring
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleFile - файл правил Prometheus
+type RuleFile struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+// RuleGroup - группа правил
+type RuleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule - правило алерта
+type Rule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// alertTemplate - базовый алерт; добавляется, если сервер отдает все метрики requires
+type alertTemplate struct {
+	requires    []string
+	alert       string
+	expr        string
+	forDuration string
+	severity    string
+	summary     string
+	description string
+}
+
+// baselineAlerts - базовые алерты сервера. В expr %[1]s заменяется селектором job,
+// %[2]s - селектором job и ответов 5xx.
+var baselineAlerts = []alertTemplate{
+	{
+		alert:       "WebServerDown",
+		expr:        `up%[1]s == 0`,
+		forDuration: "1m",
+		severity:    "critical",
+		summary:     "Web server is down",
+		description: "Web server {{ $labels.instance }} has been down for more than 1 minute.",
+	},
+	{
+		requires:    []string{"http_requests_total"},
+		alert:       "HighErrorRate",
+		expr:        "sum by (instance) (rate(http_requests_total%[2]s[5m]))\n  / sum by (instance) (rate(http_requests_total%[1]s[5m])) > 0.1",
+		forDuration: "2m",
+		severity:    "warning",
+		summary:     "High error rate",
+		description: "Error rate is {{ $value | humanizePercentage }} for {{ $labels.instance }}.",
+	},
+	{
+		requires:    []string{"http_request_duration_seconds"},
+		alert:       "HighRequestLatency",
+		expr:        `histogram_quantile(0.95, sum by (instance, le) (rate(http_request_duration_seconds_bucket%[1]s[5m]))) > 0.5`,
+		forDuration: "2m",
+		severity:    "warning",
+		summary:     "High request latency",
+		description: "95th percentile latency is {{ $value | humanizeDuration }} for {{ $labels.instance }}.",
+	},
+	{
+		requires:    []string{"http_request_duration_seconds"},
+		alert:       "HighResponseTime",
+		expr:        `histogram_quantile(0.99, sum by (instance, le) (rate(http_request_duration_seconds_bucket%[1]s[5m]))) > 1`,
+		forDuration: "2m",
+		severity:    "warning",
+		summary:     "High response time",
+		description: "99th percentile response time is {{ $value | humanizeDuration }} for {{ $labels.instance }}.",
+	},
+	{
+		requires:    []string{"go_memstats_heap_alloc_bytes"},
+		alert:       "HighMemoryUsage",
+		expr:        `go_memstats_heap_alloc_bytes%[1]s > 100 * 1024 * 1024`,
+		forDuration: "5m",
+		severity:    "warning",
+		summary:     "High memory usage",
+		description: "Heap usage is {{ $value | humanize1024 }}B for {{ $labels.instance }}.",
+	},
+	{
+		requires:    []string{"go_goroutines"},
+		alert:       "TooManyGoroutines",
+		expr:        `go_goroutines%[1]s > 1000`,
+		forDuration: "5m",
+		severity:    "warning",
+		summary:     "Too many goroutines",
+		description: "Number of goroutines is {{ $value }} for {{ $labels.instance }}.",
+	},
+	{
+		requires:    []string{"process_open_fds", "process_max_fds"},
+		alert:       "FileDescriptorsExhausted",
+		expr:        `process_open_fds%[1]s / process_max_fds%[1]s > 0.8`,
+		forDuration: "5m",
+		severity:    "warning",
+		summary:     "File descriptors almost exhausted",
+		description: "{{ $value | humanizePercentage }} of file descriptors are open on {{ $labels.instance }}.",
+	},
+	{
+		requires:    []string{"metrics_cardinality_overflow_total"},
+		alert:       "MetricsCardinalityOverflow",
+		expr:        `sum by (instance, metric) (increase(metrics_cardinality_overflow_total%[1]s[15m])) > 0`,
+		severity:    "info",
+		summary:     "Metric {{ $labels.metric }} reached its series limit",
+		description: "New label values of {{ $labels.metric }} on {{ $labels.instance }} are recorded as \"other\"; check the server log and METRICS_MAX_SERIES.",
+	},
+	{
+		requires:    []string{"slo_error_budget_remaining_ratio"},
+		alert:       "SLOErrorBudgetExhausted",
+		expr:        `slo_error_budget_remaining_ratio%[1]s <= 0`,
+		forDuration: "15m",
+		severity:    "warning",
+		summary:     "SLO {{ $labels.slo }} has exhausted its error budget",
+		description: "Error budget of SLO {{ $labels.slo }} on {{ $labels.instance }} is exhausted since the process start.",
+	},
+}
+
+// Alerts строит базовые алерты для метрик из каталога. job - значение
+// метки job, под которой Prometheus собирает метрики сервера.
+func Alerts(c *Catalog, job string) RuleFile {
+	selector := fmt.Sprintf(`{job=%q}`, job)
+	errors := fmt.Sprintf(`{job=%q,status=~"5.."}`, job)
+
+	group := RuleGroup{Name: job + "-alerts"}
+	for _, tmpl := range baselineAlerts {
+		if !c.hasAll(tmpl.requires) {
+			continue
+		}
+		group.Rules = append(group.Rules, Rule{
+			Alert:       tmpl.alert,
+			Expr:        fmt.Sprintf(tmpl.expr, selector, errors),
+			For:         tmpl.forDuration,
+			Labels:      map[string]string{"severity": tmpl.severity},
+			Annotations: map[string]string{"summary": tmpl.summary, "description": tmpl.description},
+		})
+	}
+	return RuleFile{Groups: []RuleGroup{group}}
+}
+
+// AlertsYAML возвращает базовые алерты в формате файла правил Prometheus
+func AlertsYAML(c *Catalog, job string) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString("# Generated by `server monitoring alerts`. DO NOT EDIT.\n")
+
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(Alerts(c, job)); err != nil {
+		return nil, fmt.Errorf("failed to encode alerts: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// hasAll сообщает, отдаются ли все метрики names
+func (c *Catalog) hasAll(names []string) bool {
+	for _, name := range names {
+		if !c.Has(name) {
+			return false
+		}
+	}
+	return true
+}
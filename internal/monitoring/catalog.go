@@ -0,0 +1,114 @@
+// Package monitoring генерирует дашборд Grafana и базовые правила алертов
+// Prometheus по метрикам, которые действительно отдает сервер, и проверяет,
+// что готовые дашборды и правила не ссылаются на несуществующие метрики.
+package monitoring
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Типы метрик в каталоге
+const (
+	Counter   = "counter"
+	Gauge     = "gauge"
+	Histogram = "histogram"
+	Summary   = "summary"
+	Untyped   = "untyped"
+)
+
+// Metric - описание экспортируемой метрики
+type Metric struct {
+	Name   string
+	Type   string
+	Help   string
+	Labels []string
+}
+
+// Catalog - набор метрик, которые отдает registry
+type Catalog struct {
+	metrics map[string]Metric
+}
+
+// NewCatalog собирает каталог из registry. Векторы без единого наблюдения
+// registry не отдает, поэтому перед сбором сервер должен обработать запрос.
+func NewCatalog(g prometheus.Gatherer) (*Catalog, error) {
+	families, err := g.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	c := &Catalog{metrics: make(map[string]Metric, len(families))}
+	for _, mf := range families {
+		m := Metric{
+			Name: mf.GetName(),
+			Type: strings.ToLower(mf.GetType().String()),
+			Help: mf.GetHelp(),
+		}
+		if mf.GetType() == dto.MetricType_GAUGE_HISTOGRAM {
+			m.Type = Histogram
+		}
+
+		labels := make(map[string]bool)
+		for _, metric := range mf.GetMetric() {
+			for _, lp := range metric.GetLabel() {
+				labels[lp.GetName()] = true
+			}
+		}
+		for name := range labels {
+			m.Labels = append(m.Labels, name)
+		}
+		sort.Strings(m.Labels)
+
+		c.metrics[m.Name] = m
+	}
+	return c, nil
+}
+
+// Get возвращает метрику по имени семейства
+func (c *Catalog) Get(name string) (Metric, bool) {
+	m, ok := c.metrics[name]
+	return m, ok
+}
+
+// Has сообщает, отдается ли семейство метрик с именем name
+func (c *Catalog) Has(name string) bool {
+	_, ok := c.metrics[name]
+	return ok
+}
+
+// Exports сообщает, отдается ли серия с именем name: имя семейства или
+// серия гистограммы и summary (_bucket, _sum, _count)
+func (c *Catalog) Exports(name string) bool {
+	if c.Has(name) {
+		return true
+	}
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		base, ok := strings.CutSuffix(name, suffix)
+		if !ok {
+			continue
+		}
+		m, found := c.metrics[base]
+		if !found {
+			continue
+		}
+		if m.Type == Histogram || (m.Type == Summary && suffix != "_bucket") {
+			return true
+		}
+	}
+	return false
+}
+
+// Names возвращает имена семейств в алфавитном порядке
+func (c *Catalog) Names() []string {
+	names := make([]string, 0, len(c.metrics))
+	for name := range c.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
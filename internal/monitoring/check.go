@@ -0,0 +1,201 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Синтетические серии, которые Prometheus добавляет к каждому scrape сам
+var scrapeSeries = map[string]bool{
+	"up":                                    true,
+	"scrape_duration_seconds":               true,
+	"scrape_samples_scraped":                true,
+	"scrape_samples_post_metric_relabeling": true,
+	"scrape_series_added":                   true,
+}
+
+var (
+	// selectorPattern находит селекторы вида name{matchers}; значения в кавычках
+	// могут содержать "}" (job="${job}")
+	selectorPattern = regexp.MustCompile(`([a-zA-Z_:][a-zA-Z0-9_:]*)\s*\{((?:[^}"]|"(?:[^"\\]|\\.)*")*)\}`)
+	// matcherPattern разбирает один matcher: label op "value"
+	matcherPattern = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*"((?:[^"\\]|\\.)*)"`)
+
+	// Части выражения, в которых нет имен метрик: селекторы с matchers, строки,
+	// диапазоны и offset, списки меток группировки, переменные Grafana
+	bracesPattern   = regexp.MustCompile(`\{(?:[^}"]|"(?:[^"\\]|\\.)*")*\}`)
+	stringPattern   = regexp.MustCompile("\"(?:[^\"\\\\]|\\\\.)*\"|'(?:[^'\\\\]|\\\\.)*'|`[^`]*`")
+	rangePattern    = regexp.MustCompile(`\[[^\]]*\]`)
+	groupingPattern = regexp.MustCompile(`\b(by|without|on|ignoring|group_left|group_right)\s*\([^)]*\)`)
+	variablePattern = regexp.MustCompile(`\$\w+`)
+	namePattern     = regexp.MustCompile(`[a-zA-Z_:][a-zA-Z0-9_:]*`)
+)
+
+// keywords - слова PromQL, которые не являются именами метрик
+var keywords = map[string]bool{
+	"and": true, "or": true, "unless": true, "bool": true, "offset": true,
+	"by": true, "without": true, "on": true, "ignoring": true,
+	"group_left": true, "group_right": true, "inf": true, "nan": true,
+}
+
+// References возвращает имена метрик, которые выражение PromQL может выбрать у
+// job: селекторы без метки job, с job="<job>" и с job из переменной Grafana
+// ($job). Селекторы другого job (node-exporter) и записывающие правила (имена
+// с ":") пропускаются.
+func References(expr, job string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if strings.Contains(name, ":") || seen[name] {
+			return
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	for _, m := range selectorPattern.FindAllStringSubmatch(expr, -1) {
+		if selectsJob(m[2], job) {
+			add(m[1])
+		}
+	}
+
+	// Селекторы без matchers: rate(http_requests_total[5m])
+	rest := selectorPattern.ReplaceAllString(expr, " ")
+	for _, p := range []*regexp.Regexp{bracesPattern, stringPattern, rangePattern, groupingPattern, variablePattern} {
+		rest = p.ReplaceAllString(rest, " ")
+	}
+	for _, loc := range namePattern.FindAllStringIndex(rest, -1) {
+		name := rest[loc[0]:loc[1]]
+		// Часть числа или длительности: 1e3, 5m
+		if loc[0] > 0 && strings.ContainsAny(rest[loc[0]-1:loc[0]], "0123456789.") {
+			continue
+		}
+		// Функция или агрегация: rate(, sum (
+		if next := strings.TrimLeft(rest[loc[1]:], " \t\n"); strings.HasPrefix(next, "(") {
+			continue
+		}
+		if keywords[strings.ToLower(name)] {
+			continue
+		}
+		add(name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// selectsJob сообщает, может ли селектор с matchers выбрать серии job.
+// Значения с переменными Grafana ($job, [[job]]) считаются совпадающими.
+func selectsJob(matchers, job string) bool {
+	for _, m := range matcherPattern.FindAllStringSubmatch(matchers, -1) {
+		label, op, value := m[1], m[2], m[3]
+		if label != "job" || strings.Contains(value, "$") || strings.Contains(value, "[[") {
+			continue
+		}
+		var match bool
+		switch op {
+		case "=", "!=":
+			match = value == job
+		default:
+			re, err := regexp.Compile("^(?:" + value + ")$")
+			if err != nil {
+				return false
+			}
+			match = re.MatchString(job)
+		}
+		if match != (op == "=" || op == "=~") {
+			return false
+		}
+	}
+	return true
+}
+
+// unknown возвращает метрики выражения, которые сервер не отдает
+func (c *Catalog) unknown(expr, job string) []string {
+	var missing []string
+	for _, name := range References(expr, job) {
+		if !scrapeSeries[name] && !c.Exports(name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// CheckRules проверяет файл правил Prometheus и возвращает описания выражений,
+// ссылающихся на метрики job, которых нет в каталоге
+func CheckRules(data []byte, c *Catalog, job string) ([]string, error) {
+	var file struct {
+		Groups []struct {
+			Name  string `yaml:"name"`
+			Rules []struct {
+				Record string `yaml:"record"`
+				Alert  string `yaml:"alert"`
+				Expr   string `yaml:"expr"`
+			} `yaml:"rules"`
+		} `yaml:"groups"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid rule file: %w", err)
+	}
+
+	var problems []string
+	for _, g := range file.Groups {
+		for _, r := range g.Rules {
+			name := r.Alert
+			if name == "" {
+				name = r.Record
+			}
+			for _, metric := range c.unknown(r.Expr, job) {
+				problems = append(problems, fmt.Sprintf("%s/%s: unknown metric %s", g.Name, name, metric))
+			}
+		}
+	}
+	return problems, nil
+}
+
+// CheckDashboard проверяет запросы панелей дашборда Grafana (включая панели
+// внутри свернутых строк) так же, как CheckRules
+func CheckDashboard(data []byte, c *Catalog, job string) ([]string, error) {
+	type jsonPanel struct {
+		Title   string `json:"title"`
+		Targets []struct {
+			Expr string `json:"expr"`
+		} `json:"targets"`
+		Panels []json.RawMessage `json:"panels"`
+	}
+	var d struct {
+		Panels []json.RawMessage `json:"panels"`
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("invalid dashboard: %w", err)
+	}
+
+	var problems []string
+	var walk func(raw []json.RawMessage) error
+	walk = func(raw []json.RawMessage) error {
+		for _, r := range raw {
+			var p jsonPanel
+			if err := json.Unmarshal(r, &p); err != nil {
+				return fmt.Errorf("invalid dashboard panel: %w", err)
+			}
+			for _, t := range p.Targets {
+				for _, metric := range c.unknown(t.Expr, job) {
+					problems = append(problems, fmt.Sprintf("panel %q: unknown metric %s", p.Title, metric))
+				}
+			}
+			if err := walk(p.Panels); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(d.Panels); err != nil {
+		return nil, err
+	}
+	return problems, nil
+}
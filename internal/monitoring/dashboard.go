@@ -0,0 +1,361 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Route - маршрут сервера для RED панелей
+type Route struct {
+	Method string
+	Path   string
+}
+
+// DashboardOptions задает заголовок и идентификатор дашборда
+type DashboardOptions struct {
+	// Job - значение метки job, под которой Prometheus собирает метрики сервера
+	Job   string
+	Title string
+	UID   string
+	// Routes - маршруты, для которых строятся строки Rate/Errors/Duration
+	Routes []Route
+}
+
+// Размеры сетки Grafana: ширина строки 24, панели по три в ряд
+const (
+	gridWidth   = 24
+	panelWidth  = 8
+	panelHeight = 8
+)
+
+// dashboard - подмножество модели дашборда Grafana, которое использует генератор
+type dashboard struct {
+	Annotations   annotations `json:"annotations"`
+	Editable      bool        `json:"editable"`
+	GraphTooltip  int         `json:"graphTooltip"`
+	Links         []any       `json:"links"`
+	Panels        []panel     `json:"panels"`
+	Refresh       string      `json:"refresh"`
+	SchemaVersion int         `json:"schemaVersion"`
+	Tags          []string    `json:"tags"`
+	Templating    templating  `json:"templating"`
+	Time          timeRange   `json:"time"`
+	Timezone      string      `json:"timezone"`
+	Title         string      `json:"title"`
+	UID           string      `json:"uid"`
+	Version       int         `json:"version"`
+}
+
+type annotations struct {
+	List []annotation `json:"list"`
+}
+
+type annotation struct {
+	BuiltIn    int    `json:"builtIn"`
+	Datasource string `json:"datasource"`
+	Enable     bool   `json:"enable"`
+	Hide       bool   `json:"hide"`
+	IconColor  string `json:"iconColor"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+}
+
+type templating struct {
+	List []variable `json:"list"`
+}
+
+type variable struct {
+	Datasource string `json:"datasource"`
+	IncludeAll bool   `json:"includeAll"`
+	AllValue   string `json:"allValue,omitempty"`
+	Label      string `json:"label"`
+	Multi      bool   `json:"multi"`
+	Name       string `json:"name"`
+	Query      string `json:"query"`
+	Refresh    int    `json:"refresh"`
+	Type       string `json:"type"`
+}
+
+type timeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type panel struct {
+	Collapsed   *bool        `json:"collapsed,omitempty"`
+	Datasource  string       `json:"datasource,omitempty"`
+	FieldConfig *fieldConfig `json:"fieldConfig,omitempty"`
+	GridPos     gridPos      `json:"gridPos"`
+	ID          int          `json:"id"`
+	Targets     []target     `json:"targets,omitempty"`
+	Title       string       `json:"title"`
+	Type        string       `json:"type"`
+}
+
+type fieldConfig struct {
+	Defaults  fieldDefaults `json:"defaults"`
+	Overrides []any         `json:"overrides"`
+}
+
+type fieldDefaults struct {
+	Unit string `json:"unit"`
+	Min  *int   `json:"min,omitempty"`
+}
+
+type gridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type target struct {
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+	RefID        string `json:"refId"`
+}
+
+// query - запрос панели с легендой
+type query struct {
+	expr   string
+	legend string
+}
+
+// panelSpec - панель до размещения на сетке
+type panelSpec struct {
+	title   string
+	kind    string
+	unit    string
+	queries []query
+}
+
+// dashboardBuilder размещает строки и панели на сетке Grafana
+type dashboardBuilder struct {
+	panels []panel
+	nextID int
+	y      int
+}
+
+// row добавляет строку с заголовком title и панелями specs. Пустые строки пропускаются.
+func (b *dashboardBuilder) row(title string, specs []panelSpec) {
+	if len(specs) == 0 {
+		return
+	}
+
+	collapsed := false
+	b.nextID++
+	b.panels = append(b.panels, panel{
+		Collapsed: &collapsed,
+		GridPos:   gridPos{H: 1, W: gridWidth, X: 0, Y: b.y},
+		ID:        b.nextID,
+		Title:     title,
+		Type:      "row",
+	})
+	b.y++
+
+	for i, spec := range specs {
+		x := (i * panelWidth) % gridWidth
+		if i > 0 && x == 0 {
+			b.y += panelHeight
+		}
+
+		targets := make([]target, len(spec.queries))
+		for j, q := range spec.queries {
+			targets[j] = target{Expr: q.expr, LegendFormat: q.legend, RefID: string(rune('A' + j))}
+		}
+
+		zero := 0
+		b.nextID++
+		b.panels = append(b.panels, panel{
+			Datasource:  "Prometheus",
+			FieldConfig: &fieldConfig{Defaults: fieldDefaults{Unit: spec.unit, Min: &zero}, Overrides: []any{}},
+			GridPos:     gridPos{H: panelHeight, W: panelWidth, X: x, Y: b.y},
+			ID:          b.nextID,
+			Targets:     targets,
+			Title:       spec.title,
+			Type:        spec.kind,
+		})
+	}
+	b.y += panelHeight
+}
+
+// Dashboard строит дашборд Grafana по метрикам каталога: общие RED панели,
+// RED панели каждого маршрута, SLO и рантайм Go/процесса. Панели метрик,
+// которых нет в каталоге, не добавляются.
+func Dashboard(c *Catalog, opts DashboardOptions) ([]byte, error) {
+	sel := selector{job: opts.Job}
+	b := &dashboardBuilder{}
+
+	b.row("Overview", overviewPanels(c, sel))
+	for _, route := range opts.Routes {
+		b.row(route.Method+" "+route.Path, redPanels(c, sel.route(route)))
+	}
+	b.row("SLO", sloPanels(c, sel))
+	b.row("Runtime", runtimePanels(c, sel))
+
+	d := dashboard{
+		Annotations: annotations{List: []annotation{{
+			BuiltIn:    1,
+			Datasource: "-- Grafana --",
+			Enable:     true,
+			Hide:       true,
+			IconColor:  "rgba(0, 211, 255, 1)",
+			Name:       "Annotations & Alerts",
+			Type:       "dashboard",
+		}}},
+		Editable:      true,
+		Links:         []any{},
+		Panels:        b.panels,
+		Refresh:       "30s",
+		SchemaVersion: 27,
+		Tags:          []string{"golang", "prometheus", "monitoring", "generated"},
+		Templating: templating{List: []variable{{
+			Datasource: "Prometheus",
+			IncludeAll: true,
+			AllValue:   ".*",
+			Label:      "Instance",
+			Multi:      true,
+			Name:       "instance",
+			Query:      fmt.Sprintf(`label_values(up{job=%q}, instance)`, opts.Job),
+			Refresh:    2,
+			Type:       "query",
+		}}},
+		Time:    timeRange{From: "now-1h", To: "now"},
+		Title:   opts.Title,
+		UID:     opts.UID,
+		Version: 1,
+	}
+
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode dashboard: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// selector строит селекторы PromQL с меткой job, переменной instance и метками маршрута
+type selector struct {
+	job      string
+	matchers []string
+}
+
+// route добавляет метки method и endpoint маршрута. Сервер пишет в endpoint
+// шаблон маршрута ("/items/{id}"), поэтому достаточно точного совпадения.
+func (s selector) route(r Route) selector {
+	s.matchers = append(append([]string(nil), s.matchers...), fmt.Sprintf(`method=%q`, r.Method), fmt.Sprintf(`endpoint=%q`, r.Path))
+	return s
+}
+
+// with возвращает селектор с дополнительными метками
+func (s selector) with(matchers ...string) string {
+	all := append([]string{fmt.Sprintf(`job=%q`, s.job), `instance=~"$instance"`}, s.matchers...)
+	return "{" + strings.Join(append(all, matchers...), ",") + "}"
+}
+
+// String возвращает селектор без дополнительных меток
+func (s selector) String() string {
+	return s.with()
+}
+
+// overviewPanels - RED панели по всем маршрутам и информация о сборке
+func overviewPanels(c *Catalog, sel selector) []panelSpec {
+	specs := redPanels(c, sel)
+	if c.Has("server_uptime_seconds") {
+		specs = append(specs, panelSpec{title: "Uptime", kind: "stat", unit: "s", queries: []query{
+			{expr: "server_uptime_seconds" + sel.String(), legend: "{{instance}}"},
+		}})
+	}
+	if c.Has("build_info") {
+		specs = append(specs, panelSpec{title: "Build", kind: "table", unit: "none", queries: []query{
+			{expr: "build_info" + sel.String(), legend: "{{version}} ({{commit}})"},
+		}})
+	}
+	return specs
+}
+
+// redPanels - Rate, Errors и Duration для селектора
+func redPanels(c *Catalog, sel selector) []panelSpec {
+	var specs []panelSpec
+	if c.Has("http_requests_total") {
+		specs = append(specs,
+			panelSpec{title: "Request rate", kind: "timeseries", unit: "reqps", queries: []query{
+				{expr: fmt.Sprintf("sum by (status) (rate(http_requests_total%s[5m]))", sel), legend: "{{status}}"},
+			}},
+			panelSpec{title: "Error ratio (5xx)", kind: "timeseries", unit: "percentunit", queries: []query{
+				{expr: fmt.Sprintf("sum(rate(http_requests_total%s[5m])) / sum(rate(http_requests_total%s[5m]))",
+					sel.with(`status=~"5.."`), sel), legend: "5xx"},
+			}},
+		)
+	}
+	if m, ok := c.Get("http_request_duration_seconds"); ok && m.Type == Histogram {
+		var queries []query
+		for _, q := range []string{"0.5", "0.95", "0.99"} {
+			queries = append(queries, query{
+				expr:   fmt.Sprintf("histogram_quantile(%s, sum by (le) (rate(http_request_duration_seconds_bucket%s[5m])))", q, sel),
+				legend: "p" + strings.TrimPrefix(q, "0."),
+			})
+		}
+		specs = append(specs, panelSpec{title: "Latency", kind: "timeseries", unit: "s", queries: queries})
+	}
+	return specs
+}
+
+// sloPanels - доля хороших событий и остаток бюджета ошибок
+func sloPanels(c *Catalog, sel selector) []panelSpec {
+	var specs []panelSpec
+	if c.Has("slo_events_total") && c.Has("slo_good_events_total") {
+		specs = append(specs, panelSpec{title: "SLI (good events ratio, 1h)", kind: "timeseries", unit: "percentunit", queries: []query{
+			{expr: fmt.Sprintf("sum by (slo) (rate(slo_good_events_total%s[1h])) / sum by (slo) (rate(slo_events_total%s[1h]))", sel, sel),
+				legend: "{{slo}}"},
+		}})
+	}
+	if c.Has("slo_objective_ratio") {
+		specs = append(specs, panelSpec{title: "Objectives", kind: "stat", unit: "percentunit", queries: []query{
+			{expr: fmt.Sprintf("max by (slo) (slo_objective_ratio%s)", sel), legend: "{{slo}}"},
+		}})
+	}
+	if c.Has("slo_error_budget_remaining_ratio") {
+		specs = append(specs, panelSpec{title: "Error budget remaining (since start)", kind: "timeseries", unit: "percentunit", queries: []query{
+			{expr: "slo_error_budget_remaining_ratio" + sel.String(), legend: "{{slo}} {{instance}}"},
+		}})
+	}
+	return specs
+}
+
+// runtimePanels - рантайм Go и ресурсы процесса
+func runtimePanels(c *Catalog, sel selector) []panelSpec {
+	var specs []panelSpec
+	if c.Has("go_goroutines") {
+		specs = append(specs, panelSpec{title: "Goroutines", kind: "timeseries", unit: "short", queries: []query{
+			{expr: "go_goroutines" + sel.String(), legend: "{{instance}}"},
+		}})
+	}
+	if c.Has("go_memstats_heap_alloc_bytes") && c.Has("go_memstats_heap_inuse_bytes") {
+		specs = append(specs, panelSpec{title: "Heap", kind: "timeseries", unit: "bytes", queries: []query{
+			{expr: "go_memstats_heap_alloc_bytes" + sel.String(), legend: "alloc {{instance}}"},
+			{expr: "go_memstats_heap_inuse_bytes" + sel.String(), legend: "in use {{instance}}"},
+		}})
+	}
+	if c.Exports("go_gc_duration_seconds_sum") {
+		specs = append(specs, panelSpec{title: "GC pause time", kind: "timeseries", unit: "s", queries: []query{
+			{expr: fmt.Sprintf("rate(go_gc_duration_seconds_sum%s[5m])", sel), legend: "{{instance}}"},
+		}})
+	}
+	if c.Has("process_cpu_seconds_total") {
+		specs = append(specs, panelSpec{title: "CPU", kind: "timeseries", unit: "percentunit", queries: []query{
+			{expr: fmt.Sprintf("rate(process_cpu_seconds_total%s[5m])", sel), legend: "{{instance}}"},
+		}})
+	}
+	if c.Has("process_resident_memory_bytes") {
+		specs = append(specs, panelSpec{title: "Resident memory", kind: "timeseries", unit: "bytes", queries: []query{
+			{expr: "process_resident_memory_bytes" + sel.String(), legend: "{{instance}}"},
+		}})
+	}
+	if c.Has("process_open_fds") && c.Has("process_max_fds") {
+		specs = append(specs, panelSpec{title: "Open file descriptors", kind: "timeseries", unit: "percentunit", queries: []query{
+			{expr: fmt.Sprintf("process_open_fds%s / process_max_fds%s", sel, sel), legend: "{{instance}}"},
+		}})
+	}
+	return specs
+}
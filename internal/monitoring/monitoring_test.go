@@ -0,0 +1,217 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newTestCatalog собирает каталог из registry с метриками запросов и горутин
+func newTestCatalog(t *testing.T) *Catalog {
+	t.Helper()
+
+	reg := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "http_requests_total", Help: "Requests."},
+		[]string{"method", "endpoint", "status"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "http_request_duration_seconds", Help: "Duration."},
+		[]string{"method", "endpoint"})
+	goroutines := prometheus.NewGauge(prometheus.GaugeOpts{Name: "go_goroutines", Help: "Goroutines."})
+	reg.MustRegister(requests, duration, goroutines)
+
+	requests.WithLabelValues("GET", "/health", "200").Inc()
+	duration.WithLabelValues("GET", "/health").Observe(0.01)
+
+	c, err := NewCatalog(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCatalog(t *testing.T) {
+	c := newTestCatalog(t)
+
+	m, ok := c.Get("http_requests_total")
+	if !ok || m.Type != Counter || !reflect.DeepEqual(m.Labels, []string{"endpoint", "method", "status"}) {
+		t.Errorf("unexpected http_requests_total: %+v", m)
+	}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"http_requests_total", true},
+		{"http_request_duration_seconds_bucket", true},
+		{"http_request_duration_seconds_count", true},
+		{"http_requests_total_bucket", false},
+		{"go_goroutines", true},
+		{"go_threads", false},
+	}
+	for _, tt := range tests {
+		if got := c.Exports(tt.name); got != tt.want {
+			t.Errorf("Exports(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want []string
+	}{
+		{
+			name: "ratio",
+			expr: `sum(rate(http_requests_total{job="web-server",status=~"5.."}[5m])) / sum(rate(http_requests_total{job="web-server"}[5m]))`,
+			want: []string{"http_requests_total"},
+		},
+		{
+			name: "spaces in matchers",
+			expr: `go_goroutines{ instance=~"$instance", job = "web-server" } > 1000`,
+			want: []string{"go_goroutines"},
+		},
+		{
+			name: "other job",
+			expr: `node_load1{job="node-exporter"} > 4`,
+		},
+		{
+			name: "recording rule without job",
+			expr: `slo:sli_error:ratio_rate1h{slo="api"} > 0.01`,
+		},
+		{
+			name: "job prefix does not match",
+			expr: `up{job="web-server-canary"} == 0`,
+		},
+		{
+			name: "bare selectors",
+			expr: `sum by (route) (rate(http_requests_total[5m] offset 1h)) / 1e3 and on(instance) process_open_fds > bool 0`,
+			want: []string{"http_requests_total", "process_open_fds"},
+		},
+		{
+			name: "selector without job matcher",
+			expr: `histogram_quantile(0.99, sum by (le) (rate(http_request_duration_seconds_bucket{route="GET /"}[$__rate_interval])))`,
+			want: []string{"http_request_duration_seconds_bucket"},
+		},
+		{
+			name: "templated job",
+			expr: `go_goroutines{job=~"$job"} + go_threads{job="${job}"} + label_replace(go_gc_duration_seconds_count{job="[[job]]"}, "dst", "$1", "src", "(.*)")`,
+			want: []string{"go_gc_duration_seconds_count", "go_goroutines", "go_threads"},
+		},
+		{
+			name: "job regex",
+			expr: `up{job=~"web-.*"} + up{job=~"node.*"} + process_start_time_seconds{job!="web-server"}`,
+			want: []string{"up"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := References(tt.expr, "web-server"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("References() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlerts_OnlyForExportedMetrics(t *testing.T) {
+	file := Alerts(newTestCatalog(t), "web-server")
+
+	var names []string
+	for _, r := range file.Groups[0].Rules {
+		names = append(names, r.Alert)
+	}
+	want := []string{"WebServerDown", "HighErrorRate", "HighRequestLatency", "HighResponseTime", "TooManyGoroutines"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("alerts = %v, want %v", names, want)
+	}
+
+	data, err := AlertsYAML(newTestCatalog(t), "web-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems, err := CheckRules(data, newTestCatalog(t), "web-server")
+	if err != nil || len(problems) != 0 {
+		t.Errorf("generated alerts reference unknown metrics: %v %v", problems, err)
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	c := newTestCatalog(t)
+	data, err := Dashboard(c, DashboardOptions{
+		Job:   "web-server",
+		Title: "Test",
+		UID:   "test",
+		Routes: []Route{
+			{Method: "GET", Path: "/health"},
+			{Method: "GET", Path: "/items/{id}"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var d struct {
+		UID    string `json:"uid"`
+		Panels []struct {
+			Type    string `json:"type"`
+			Title   string `json:"title"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		t.Fatalf("invalid dashboard JSON: %v", err)
+	}
+	if d.UID != "test" {
+		t.Errorf("expected uid test, got %q", d.UID)
+	}
+
+	var rows []string
+	var exprs []string
+	for _, p := range d.Panels {
+		if p.Type == "row" {
+			rows = append(rows, p.Title)
+		}
+		for _, target := range p.Targets {
+			exprs = append(exprs, target.Expr)
+		}
+	}
+	// Строки SLO нет: в каталоге нет метрик SLO
+	if want := []string{"Overview", "GET /health", "GET /items/{id}", "Runtime"}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+
+	all := strings.Join(exprs, "\n")
+	for _, want := range []string{
+		`method="GET",endpoint="/health"`,
+		`method="GET",endpoint="/items/{id}"`,
+		`go_goroutines{job="web-server",instance=~"$instance"}`,
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("expected dashboard queries to contain %q", want)
+		}
+	}
+
+	problems, err := CheckDashboard(data, c, "web-server")
+	if err != nil || len(problems) != 0 {
+		t.Errorf("generated dashboard references unknown metrics: %v %v", problems, err)
+	}
+}
+
+func TestCheckDashboard_UnknownMetric(t *testing.T) {
+	data := []byte(`{"panels": [{"type": "row", "title": "Collapsed", "panels": [
+		{"title": "Memory", "targets": [{"expr": "go_memstats_alloc_bytes{job=\"web-server\"}"}]}
+	]}]}`)
+
+	problems, err := CheckDashboard(data, newTestCatalog(t), "web-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0], "go_memstats_alloc_bytes") {
+		t.Errorf("expected unknown go_memstats_alloc_bytes, got %v", problems)
+	}
+}
@@ -0,0 +1,211 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version - версия спецификации OpenAPI генерируемого документа
+const Version = "3.1.0"
+
+// Document - OpenAPI документ
+type Document struct {
+	OpenAPI    string                         `json:"openapi"`
+	Info       Info                           `json:"info"`
+	Paths      map[string]map[string]*OpEntry `json:"paths"`
+	Components Components                     `json:"components"`
+
+	registry *schemaRegistry
+}
+
+// Info содержит метаданные API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components содержит переиспользуемые схемы
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// OpEntry - операция в сериализованном виде
+type OpEntry struct {
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	OperationID string               `json:"operationId,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []ParameterEntry     `json:"parameters,omitempty"`
+	RequestBody *RequestBodyEntry    `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// ParameterEntry - параметр операции в сериализованном виде
+type ParameterEntry struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBodyEntry - тело запроса в сериализованном виде
+type RequestBodyEntry struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response - ответ операции
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType связывает content type со схемой
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// NewDocument создает пустой документ
+func NewDocument(info Info) *Document {
+	registry := newSchemaRegistry()
+	return &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      make(map[string]map[string]*OpEntry),
+		Components: Components{Schemas: registry.schemas},
+		registry:   registry,
+	}
+}
+
+// Add добавляет операцию для метода и пути (паттерн ServeMux)
+func (d *Document) Add(method, path string, op *Operation) {
+	if op == nil {
+		op = NewOperation("")
+	}
+
+	entry := &OpEntry{
+		Summary:     op.Summary,
+		Description: op.Description,
+		OperationID: op.ID,
+		Tags:        op.Tags,
+		Responses:   make(map[string]*Response),
+	}
+
+	apiPath := specPath(path)
+	declared := make(map[string]bool)
+	for _, p := range op.Params {
+		declared[p.In+":"+p.Name] = true
+		entry.Parameters = append(entry.Parameters, ParameterEntry{
+			Name:        p.Name,
+			In:          p.In,
+			Description: p.Description,
+			Required:    p.Required || p.In == InPath,
+			Schema:      d.registry.schemaFor(p.Schema),
+		})
+	}
+	// Параметры пути, не описанные явно, добавляются как строки
+	for _, name := range pathParams(path) {
+		if !declared[InPath+":"+name] {
+			entry.Parameters = append(entry.Parameters, ParameterEntry{
+				Name: name, In: InPath, Required: true, Schema: &Schema{Type: "string"},
+			})
+		}
+	}
+
+	if op.Body != nil {
+		entry.RequestBody = &RequestBodyEntry{
+			Required: op.Body.Required,
+			Content: map[string]*MediaType{
+				op.Body.ContentType: {Schema: d.registry.schemaFor(op.Body.Model)},
+			},
+		}
+	}
+
+	// Несколько ResponseSpec с одним статусом объединяются в один ответ с разными content type
+	for _, resp := range op.Responses {
+		status := strconv.Itoa(resp.Status)
+		r, ok := entry.Responses[status]
+		if !ok {
+			r = &Response{Description: resp.Description}
+			if r.Description == "" {
+				r.Description = http.StatusText(resp.Status)
+			}
+			entry.Responses[status] = r
+		}
+		if resp.ContentType != "" {
+			if r.Content == nil {
+				r.Content = make(map[string]*MediaType)
+			}
+			r.Content[resp.ContentType] = &MediaType{Schema: d.registry.schemaFor(resp.Model)}
+		}
+	}
+	if len(entry.Responses) == 0 {
+		entry.Responses["default"] = &Response{Description: "Response"}
+	}
+
+	if d.Paths[apiPath] == nil {
+		d.Paths[apiPath] = make(map[string]*OpEntry)
+	}
+	d.Paths[apiPath][strings.ToLower(method)] = entry
+}
+
+// Schema возвращает схему компонента по имени
+func (d *Document) Schema(name string) (*Schema, bool) {
+	s, ok := d.Components.Schemas[name]
+	return s, ok
+}
+
+// Resolve раскрывает $ref схемы
+func (d *Document) Resolve(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		next, ok := d.Components.Schemas[strings.TrimPrefix(s.Ref, refPrefix)]
+		if !ok {
+			return s
+		}
+		s = next
+	}
+	return s
+}
+
+// Operation возвращает операцию по методу и пути
+func (d *Document) Operation(method, path string) (*OpEntry, bool) {
+	ops, ok := d.Paths[specPath(path)]
+	if !ok {
+		return nil, false
+	}
+	op, ok := ops[strings.ToLower(method)]
+	return op, ok
+}
+
+// SortedPaths возвращает пути документа в алфавитном порядке
+func (d *Document) SortedPaths() []string {
+	paths := make([]string, 0, len(d.Paths))
+	for p := range d.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// specPath преобразует паттерн ServeMux в путь OpenAPI: {name...} -> {name}
+func specPath(path string) string {
+	return strings.ReplaceAll(path, "...}", "}")
+}
+
+// pathParams извлекает имена параметров из паттерна пути
+func pathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name := strings.TrimSuffix(strings.Trim(segment, "{}"), "...")
+			if name != "$" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,63 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"web-server-go-docker/internal/handlers"
+)
+
+//go:embed ui.html
+var uiPage string
+
+var uiTemplate = template.Must(template.New("ui").Parse(uiPage))
+
+// Handler отдает документ, построенный функцией build, в формате JSON
+func Handler(build func() *Document) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(build()); err != nil {
+			handlers.InternalError(w, r, fmt.Errorf("encoding openapi document: %w", err))
+		}
+	}
+}
+
+// RedocVersion - версия Redoc, которую загружает страница документации по умолчанию.
+// При обновлении поменяйте REDOC_VERSION в Makefile и хеш (make redoc-sri).
+const RedocVersion = "2.1.5"
+
+// DefaultUIScriptURL - бандл Redoc фиксированной версии на CDN
+const DefaultUIScriptURL = "https://cdn.redoc.ly/redoc/v" + RedocVersion + "/bundles/redoc.standalone.js"
+
+// UIScript описывает скрипт Redoc страницы документации
+type UIScript struct {
+	// URL бандла; пустое значение - DefaultUIScriptURL. Для работы без доступа
+	// к CDN бандл можно разместить на своем сервере.
+	URL string
+	// Integrity - хеш для Subresource Integrity ("sha384-..."): браузер не выполнит
+	// скрипт, если CDN отдаст другой файл
+	Integrity string
+}
+
+// UIHandler отдает HTML страницу Redoc, загружающую спецификацию по specURL
+func UIHandler(specURL string, script UIScript) http.HandlerFunc {
+	if script.URL == "" {
+		script.URL = DefaultUIScriptURL
+	}
+	page := struct {
+		SpecURL string
+		Script  UIScript
+	}{specURL, script}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := uiTemplate.Execute(w, page); err != nil {
+			handlers.InternalError(w, r, fmt.Errorf("rendering docs page: %w", err))
+		}
+	}
+}
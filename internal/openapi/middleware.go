@@ -0,0 +1,306 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"web-server-go-docker/internal/handlers"
+	"web-server-go-docker/internal/requestid"
+)
+
+// maxBodySize ограничивает размер тела, читаемого для валидации
+const maxBodySize = 1 << 20
+
+// Validator проверяет запросы и/или ответы маршрута по его операции.
+// Реализует интерфейс middleware.Middleware.
+type Validator struct {
+	doc       *Document
+	op        *OpEntry
+	requests  bool
+	responses bool
+}
+
+// NewValidator создает Validator для операции. Если requests = true, проверяются
+// параметры и тело запроса; если responses = true, JSON ответы буферизуются и
+// тоже проверяются (только для non-production).
+func NewValidator(method, path string, op *Operation, requests, responses bool) *Validator {
+	doc := NewDocument(Info{})
+	doc.Add(method, path, op)
+	entry, _ := doc.Operation(method, path)
+	return &Validator{doc: doc, op: entry, requests: requests, responses: responses}
+}
+
+// Name возвращает имя middleware
+func (v *Validator) Name() string {
+	switch {
+	case v.requests && v.responses:
+		return "validate[request+response]"
+	case v.responses:
+		return "validate[response]"
+	}
+	return "validate[request]"
+}
+
+// Handler возвращает middleware handler
+func (v *Validator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v.requests {
+			errs, problem := v.validateRequest(r)
+			if problem != nil {
+				handlers.WriteProblem(w, r, problem)
+				return
+			}
+			if len(errs) > 0 {
+				handlers.ValidationFailed(w, r, errs)
+				return
+			}
+		}
+
+		if !v.responses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &bufferedWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.passthrough {
+			return
+		}
+
+		if errs := v.validateResponse(rec); len(errs) > 0 {
+			log.Printf("Response validation failed for %s %s (request_id=%s): %v",
+				r.Method, r.URL.Path, requestid.FromContext(r.Context()), errs)
+			p := handlers.NewProblem(http.StatusInternalServerError, "response does not match the declared schema")
+			p.Errors = errs
+			handlers.WriteProblem(w, r, p)
+			return
+		}
+
+		rec.flushTo(w)
+	})
+}
+
+// validateRequest проверяет параметры и тело запроса
+func (v *Validator) validateRequest(r *http.Request) ([]FieldError, *handlers.Problem) {
+	var errs []FieldError
+
+	for _, p := range v.op.Parameters {
+		var raw string
+		var present bool
+		switch p.In {
+		case InQuery:
+			values, ok := r.URL.Query()[p.Name]
+			present = ok
+			if ok && len(values) > 0 {
+				raw = values[0]
+			}
+		case InHeader:
+			raw = r.Header.Get(p.Name)
+			present = raw != ""
+		case InPath:
+			raw = r.PathValue(p.Name)
+			present = raw != ""
+		}
+
+		field := p.In + "." + p.Name
+		if !present {
+			if p.Required {
+				errs = append(errs, FieldError{Field: field, Message: "is required"})
+			}
+			continue
+		}
+
+		value, err := coerce(v.doc.Resolve(p.Schema), raw)
+		if err != nil {
+			errs = append(errs, FieldError{Field: field, Message: err.Error()})
+			continue
+		}
+		for _, fe := range v.doc.Validate(p.Schema, value) {
+			fe.Field = prefixField(field, fe.Field)
+			errs = append(errs, fe)
+		}
+	}
+
+	if v.op.RequestBody == nil {
+		return errs, nil
+	}
+
+	media, ok := v.op.RequestBody.Content["application/json"]
+	if !ok {
+		return errs, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		return nil, handlers.NewProblem(http.StatusBadRequest, "failed to read request body")
+	}
+	if len(body) > maxBodySize {
+		return nil, handlers.NewProblem(http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBodySize))
+	}
+	// Возвращаем тело, чтобы обработчик мог прочитать его повторно
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(body) == 0 {
+		if v.op.RequestBody.Required {
+			errs = append(errs, FieldError{Field: "body", Message: "is required"})
+		}
+		return errs, nil
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		return nil, handlers.NewProblem(http.StatusUnsupportedMediaType, "request body must be application/json")
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return append(errs, FieldError{Field: "body", Message: "is not valid JSON"}), nil
+	}
+
+	for _, fe := range v.doc.Validate(media.Schema, value) {
+		fe.Field = prefixField("body", fe.Field)
+		errs = append(errs, fe)
+	}
+
+	return errs, nil
+}
+
+// validateResponse проверяет буферизованный JSON ответ по объявленной схеме
+func (v *Validator) validateResponse(rec *bufferedWriter) []FieldError {
+	mediaType, _, _ := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	if mediaType != "application/json" {
+		return nil
+	}
+
+	resp, ok := v.op.Responses[strconv.Itoa(rec.status)]
+	if !ok {
+		resp, ok = v.op.Responses["default"]
+	}
+	if !ok {
+		return []FieldError{{Field: "status", Message: fmt.Sprintf("%d is not declared", rec.status)}}
+	}
+
+	media, ok := resp.Content["application/json"]
+	if !ok || media.Schema == nil {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(rec.body.Bytes(), &value); err != nil {
+		return []FieldError{{Field: "body", Message: "is not valid JSON"}}
+	}
+
+	return v.doc.Validate(media.Schema, value)
+}
+
+// prefixField добавляет префикс (body, query.name) к пути поля из Validate
+func prefixField(prefix, field string) string {
+	switch {
+	case field == "$":
+		return prefix
+	case strings.HasPrefix(field, "["):
+		return prefix + field
+	}
+	return prefix + "." + field
+}
+
+// coerce преобразует строковое значение параметра к типу схемы
+func coerce(s *Schema, raw string) (interface{}, error) {
+	if s == nil {
+		return raw, nil
+	}
+
+	switch s.Type {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return float64(n), nil
+	case "number":
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return f, nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be a boolean")
+		}
+		return b, nil
+	case "array":
+		parts := strings.Split(raw, ",")
+		items := make([]interface{}, 0, len(parts))
+		for _, part := range parts {
+			item, err := coerce(s.Items, part)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+
+	return raw, nil
+}
+
+// bufferedWriter накапливает ответ для проверки. При вызове Flush
+// (потоковые ответы) переключается в прямую запись без проверки.
+type bufferedWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	passthrough bool
+}
+
+func (b *bufferedWriter) WriteHeader(code int) {
+	if b.passthrough {
+		b.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if !b.wroteHeader {
+		b.status = code
+		b.wroteHeader = true
+	}
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	if b.passthrough {
+		return b.ResponseWriter.Write(p)
+	}
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
+
+// Flush переводит writer в режим прямой записи
+func (b *bufferedWriter) Flush() {
+	if !b.passthrough {
+		b.passthrough = true
+		b.flushTo(b.ResponseWriter)
+	}
+	if f, ok := b.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap позволяет http.ResponseController добраться до исходного ResponseWriter
+func (b *bufferedWriter) Unwrap() http.ResponseWriter {
+	return b.ResponseWriter
+}
+
+// flushTo отправляет накопленный ответ
+func (b *bufferedWriter) flushTo(w http.ResponseWriter) {
+	w.WriteHeader(b.status)
+	if b.body.Len() > 0 {
+		_, _ = w.Write(b.body.Bytes())
+		b.body.Reset()
+	}
+}
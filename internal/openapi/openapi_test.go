@@ -0,0 +1,387 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"web-server-go-docker/internal/handlers"
+)
+
+type testItem struct {
+	ID      int               `json:"id"`
+	Name    string            `json:"name" doc:"Item name"`
+	Kind    string            `json:"kind" enum:"a,b"`
+	Tags    []string          `json:"tags,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Child   *testItem         `json:"child,omitempty"`
+	private string
+}
+
+func TestDocumentSchemas(t *testing.T) {
+	doc := NewDocument(Info{Title: "test", Version: "1.0.0"})
+	doc.Add(http.MethodGet, "/items/{id}", NewOperation("Get item").JSON(http.StatusOK, testItem{}).Problem(http.StatusNotFound))
+
+	op, ok := doc.Operation(http.MethodGet, "/items/{id}")
+	if !ok {
+		t.Fatal("expected operation to be registered")
+	}
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != "id" || !op.Parameters[0].Required {
+		t.Errorf("expected implicit required path parameter, got %+v", op.Parameters)
+	}
+	if op.Responses["404"].Content["application/problem+json"] == nil {
+		t.Error("expected problem+json response for 404")
+	}
+
+	item, ok := doc.Schema("openapi.testItem")
+	if !ok {
+		t.Fatal("expected testItem component schema")
+	}
+	if len(item.Required) != 3 {
+		t.Errorf("expected id, name and kind to be required, got %v", item.Required)
+	}
+	if item.Properties["child"].Ref != refPrefix+"openapi.testItem" {
+		t.Errorf("expected recursive $ref, got %+v", item.Properties["child"])
+	}
+	if item.Properties["name"].Description != "Item name" {
+		t.Errorf("expected description from doc tag")
+	}
+	if _, ok := item.Properties["private"]; ok {
+		t.Error("unexported fields must not be documented")
+	}
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatalf("document must be serializable: %v", err)
+	}
+}
+
+// Problem совпадает по имени с handlers.Problem
+type Problem struct {
+	Code int `json:"code"`
+}
+
+type itemMeta struct {
+	Owner string `json:"owner"`
+	Note  string `json:"note,omitempty"`
+}
+
+type Audit struct {
+	Created string `json:"created"`
+	ID      string `json:"id"`
+}
+
+type embeddingItem struct {
+	itemMeta
+	*Audit
+	Named  Audit  `json:"named"`
+	Tagged Audit  `json:"tagged_audit,omitempty"`
+	ID     int    `json:"id"`
+	Owner  string `json:"owner_name"`
+}
+
+func TestDocumentSchemas_QualifiedNames(t *testing.T) {
+	doc := NewDocument(Info{Title: "test", Version: "1.0.0"})
+	doc.Add(http.MethodGet, "/problems", NewOperation("").JSON(http.StatusOK, Problem{}).Problem(http.StatusNotFound))
+
+	local, ok := doc.Schema("openapi.Problem")
+	if !ok || local.Properties["code"] == nil {
+		t.Fatalf("expected local Problem component, got %+v", local)
+	}
+	shared, ok := doc.Schema("handlers.Problem")
+	if !ok || shared.Properties["code"] != nil {
+		t.Fatalf("expected handlers.Problem component to stay intact, got %+v", shared)
+	}
+	if _, ok := doc.Schema("Problem"); ok {
+		t.Error("unqualified component names must not be used")
+	}
+}
+
+func TestDocumentSchemas_EmbeddedFields(t *testing.T) {
+	doc := NewDocument(Info{Title: "test", Version: "1.0.0"})
+	doc.Add(http.MethodGet, "/items", NewOperation("").JSON(http.StatusOK, embeddingItem{}))
+
+	item, ok := doc.Schema("openapi.embeddingItem")
+	if !ok {
+		t.Fatal("expected embeddingItem component schema")
+	}
+
+	for _, name := range []string{"owner", "note", "created", "named", "tagged_audit", "id", "owner_name"} {
+		if item.Properties[name] == nil {
+			t.Errorf("expected property %s, got %v", name, item.Properties)
+		}
+	}
+	for _, name := range []string{"itemMeta", "Audit"} {
+		if _, ok := item.Properties[name]; ok {
+			t.Errorf("embedded struct %s must be inlined", name)
+		}
+	}
+	// Поле внешней структуры перекрывает одноименное поле встроенной
+	if item.Properties["id"].Type != "integer" {
+		t.Errorf("expected id from the outer struct, got %+v", item.Properties["id"])
+	}
+	if item.Properties["named"].Ref != refPrefix+"openapi.Audit" {
+		t.Errorf("named struct field must stay a $ref, got %+v", item.Properties["named"])
+	}
+
+	required := strings.Join(item.Required, ",")
+	if required != "named,id,owner_name,owner" {
+		t.Errorf("unexpected required list %s", required)
+	}
+
+	body, err := json.Marshal(embeddingItem{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		t.Fatal(err)
+	}
+	if errs := doc.Validate(&Schema{Ref: refPrefix + "openapi.embeddingItem"}, value); len(errs) != 0 {
+		t.Errorf("expected encoding/json output to validate, got %+v", errs)
+	}
+}
+
+type nullableItem struct {
+	Data   []byte    `json:"data"`
+	Count  *int      `json:"count"`
+	Parent *testItem `json:"parent" doc:"Parent item"`
+}
+
+func TestDocumentSchemas_EncodingJSONTypes(t *testing.T) {
+	doc := NewDocument(Info{Title: "test", Version: "1.0.0"})
+	doc.Add(http.MethodGet, "/items", NewOperation("").JSON(http.StatusOK, &nullableItem{}))
+
+	item, ok := doc.Schema("openapi.nullableItem")
+	if !ok {
+		t.Fatal("expected nullableItem component schema")
+	}
+
+	tests := []struct {
+		property string
+		want     string
+	}{
+		{"data", `{"contentEncoding":"base64","type":["string","null"]}`},
+		{"count", `{"type":["integer","null"]}`},
+		{"parent", `{"anyOf":[{"$ref":"#/components/schemas/openapi.testItem"},{"type":"null"}],"description":"Parent item"}`},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(item.Properties[tt.property])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("property %s: expected %s, got %s", tt.property, tt.want, got)
+		}
+	}
+
+	count := 3
+	for _, v := range []nullableItem{
+		{},
+		{Data: []byte("payload"), Count: &count, Parent: &testItem{Name: "x", Kind: "a"}},
+	} {
+		body, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err != nil {
+			t.Fatal(err)
+		}
+		if errs := doc.Validate(&Schema{Ref: refPrefix + "openapi.nullableItem"}, value); len(errs) != 0 {
+			t.Errorf("expected %s to validate, got %+v", body, errs)
+		}
+	}
+
+	var value interface{}
+	json.Unmarshal([]byte(`{"data":"not base64!","count":null,"parent":null}`), &value)
+	if errs := doc.Validate(&Schema{Ref: refPrefix + "openapi.nullableItem"}, value); len(errs) != 1 || errs[0].Field != "data" {
+		t.Errorf("expected base64 error for data, got %+v", errs)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	doc := NewDocument(Info{Title: "test", Version: "1.0.0"})
+	doc.Add(http.MethodGet, "/items", NewOperation("").JSON(http.StatusOK, testItem{}))
+	schema := &Schema{Ref: refPrefix + "openapi.testItem"}
+
+	tests := []struct {
+		name   string
+		body   string
+		fields []string
+	}{
+		{"valid", `{"id": 1, "name": "x", "kind": "a", "tags": ["t"], "child": {"id": 2, "name": "y", "kind": "b"}}`, nil},
+		{"missing required", `{"id": 1, "kind": "a"}`, []string{"name"}},
+		{"wrong type", `{"id": "1", "name": "x", "kind": "a"}`, []string{"id"}},
+		{"not integer", `{"id": 1.5, "name": "x", "kind": "a"}`, []string{"id"}},
+		{"enum", `{"id": 1, "name": "x", "kind": "c"}`, []string{"kind"}},
+		{"extra field", `{"id": 1, "name": "x", "kind": "a", "extra": true}`, []string{"extra"}},
+		{"nested", `{"id": 1, "name": "x", "kind": "a", "tags": [1], "labels": {"k": 2}}`, []string{"labels.k", "tags[0]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.body), &value); err != nil {
+				t.Fatal(err)
+			}
+
+			errs := doc.Validate(schema, value)
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("expected errors for %v, got %+v", tt.fields, errs)
+			}
+			for i, field := range tt.fields {
+				if errs[i].Field != field {
+					t.Errorf("expected error for %s, got %s", field, errs[i].Field)
+				}
+			}
+		})
+	}
+}
+
+type createItemRequest struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestValidatorRequests(t *testing.T) {
+	op := NewOperation("Create item").
+		Query("dry_run", false, false, "").
+		Query("limit", 0, true, "").
+		Header("X-Tenant", "", true, "").
+		JSONBody(createItemRequest{}, true).
+		JSON(http.StatusCreated, testItem{})
+
+	v := NewValidator(http.MethodPost, "/items", op, true, false)
+	called := false
+	h := v.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		var req createItemRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("handler must be able to read the body: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	tests := []struct {
+		name        string
+		query       string
+		tenant      string
+		contentType string
+		body        string
+		status      int
+		fields      []string
+	}{
+		{"valid", "?limit=10&dry_run=true", "t1", "application/json", `{"name":"x","count":1}`, http.StatusCreated, nil},
+		{"missing query and header", "", "", "application/json", `{"name":"x","count":1}`, http.StatusBadRequest, []string{"query.limit", "header.X-Tenant"}},
+		{"bad query type", "?limit=ten&dry_run=maybe", "t1", "application/json", `{"name":"x","count":1}`, http.StatusBadRequest, []string{"query.dry_run", "query.limit"}},
+		{"body errors", "?limit=1", "t1", "application/json", `{"count":"1","extra":1}`, http.StatusBadRequest, []string{"body.name", "body.count", "body.extra"}},
+		{"invalid json", "?limit=1", "t1", "application/json", `{`, http.StatusBadRequest, []string{"body"}},
+		{"wrong content type", "?limit=1", "t1", "text/plain", `name=x`, http.StatusUnsupportedMediaType, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = false
+			req := httptest.NewRequest(http.MethodPost, "/items"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.tenant != "" {
+				req.Header.Set("X-Tenant", tt.tenant)
+			}
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if called != (tt.status == http.StatusCreated) {
+				t.Errorf("handler called = %v for status %d", called, tt.status)
+			}
+			if tt.fields == nil {
+				return
+			}
+
+			var problem handlers.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			if len(problem.Errors) != len(tt.fields) {
+				t.Fatalf("expected errors for %v, got %+v", tt.fields, problem.Errors)
+			}
+			for i, field := range tt.fields {
+				if problem.Errors[i].Field != field {
+					t.Errorf("expected error %d for %s, got %s", i, field, problem.Errors[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatorResponsesOnly_SkipsRequests(t *testing.T) {
+	op := NewOperation("Create item").
+		Query("limit", 0, true, "").
+		JSONBody(createItemRequest{}, true).
+		NoContent(http.StatusCreated)
+
+	v := NewValidator(http.MethodPost, "/items", op, false, true)
+	if v.Name() != "validate[response]" {
+		t.Errorf("unexpected name %s", v.Name())
+	}
+	h := v.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	// Запрос без обязательного параметра и с лишним полем не проверяется
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"extra":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected request to reach handler, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestValidatorResponses(t *testing.T) {
+	op := NewOperation("Get item").JSON(http.StatusOK, testItem{})
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"matching response passes through", `{"id":1,"name":"x","kind":"a"}`, http.StatusOK},
+		{"drifted response is replaced", `{"id":"1"}`, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewValidator(http.MethodGet, "/items", op, false, true).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.body))
+			}))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status == http.StatusOK && w.Body.String() != tt.body {
+				t.Errorf("expected original body, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRedocVersion_MatchesMakefile(t *testing.T) {
+	makefile, err := os.ReadFile("../../Makefile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(makefile), "REDOC_VERSION := "+RedocVersion+"\n") {
+		t.Errorf("REDOC_VERSION in Makefile must be %s (make redoc-sri hashes that bundle)", RedocVersion)
+	}
+}
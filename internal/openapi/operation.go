@@ -0,0 +1,164 @@
+package openapi
+
+import (
+	"net/http"
+	"strings"
+
+	"web-server-go-docker/internal/handlers"
+)
+
+// Расположение параметров
+const (
+	InQuery  = "query"
+	InHeader = "header"
+	InPath   = "path"
+)
+
+// Operation описывает маршрут для документации. Методы возвращают
+// саму операцию, чтобы описание можно было строить цепочкой вызовов.
+type Operation struct {
+	ID          string
+	Summary     string
+	Description string
+	Tags        []string
+	Params      []Param
+	Body        *Body
+	Responses   []ResponseSpec
+}
+
+// Param описывает параметр запроса. Schema - Go значение или *Schema.
+type Param struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Schema      interface{}
+}
+
+// Body описывает тело запроса
+type Body struct {
+	ContentType string
+	Model       interface{}
+	Required    bool
+}
+
+// ResponseSpec описывает ответ. Пустой ContentType означает ответ без тела.
+type ResponseSpec struct {
+	Status      int
+	Description string
+	ContentType string
+	Model       interface{}
+}
+
+// NewOperation создает операцию с кратким описанием
+func NewOperation(summary string) *Operation {
+	return &Operation{Summary: summary}
+}
+
+// Clone возвращает копию операции, которую можно дополнять, не меняя исходную
+func (o *Operation) Clone() *Operation {
+	c := *o
+	c.Tags = append([]string(nil), o.Tags...)
+	c.Params = append([]Param(nil), o.Params...)
+	c.Responses = append([]ResponseSpec(nil), o.Responses...)
+	if o.Body != nil {
+		body := *o.Body
+		c.Body = &body
+	}
+	return &c
+}
+
+// WithID задает operationId
+func (o *Operation) WithID(id string) *Operation {
+	o.ID = id
+	return o
+}
+
+// WithTags задает теги операции
+func (o *Operation) WithTags(tags ...string) *Operation {
+	o.Tags = append(o.Tags, tags...)
+	return o
+}
+
+// WithDescription задает подробное описание
+func (o *Operation) WithDescription(description string) *Operation {
+	o.Description = description
+	return o
+}
+
+// Query добавляет query параметр
+func (o *Operation) Query(name string, schema interface{}, required bool, description string) *Operation {
+	o.Params = append(o.Params, Param{Name: name, In: InQuery, Schema: schema, Required: required, Description: description})
+	return o
+}
+
+// Header добавляет параметр заголовка
+func (o *Operation) Header(name string, schema interface{}, required bool, description string) *Operation {
+	o.Params = append(o.Params, Param{Name: name, In: InHeader, Schema: schema, Required: required, Description: description})
+	return o
+}
+
+// JSONBody задает JSON тело запроса
+func (o *Operation) JSONBody(model interface{}, required bool) *Operation {
+	o.Body = &Body{ContentType: "application/json", Model: model, Required: required}
+	return o
+}
+
+// JSON добавляет JSON ответ
+func (o *Operation) JSON(status int, model interface{}) *Operation {
+	return o.Returns(status, "application/json", model)
+}
+
+// Returns добавляет ответ с произвольным content type
+func (o *Operation) Returns(status int, contentType string, model interface{}) *Operation {
+	o.Responses = append(o.Responses, ResponseSpec{Status: status, ContentType: contentType, Model: model})
+	return o
+}
+
+// NoContent добавляет ответ без тела
+func (o *Operation) NoContent(status int) *Operation {
+	o.Responses = append(o.Responses, ResponseSpec{Status: status})
+	return o
+}
+
+// Response возвращает описание ответа для статуса
+func (o *Operation) Response(status int) (ResponseSpec, bool) {
+	for _, r := range o.Responses {
+		if r.Status == status {
+			return r, true
+		}
+	}
+	return ResponseSpec{}, false
+}
+
+// Problem добавляет ответы об ошибках в формате RFC 7807
+func (o *Operation) Problem(statuses ...int) *Operation {
+	for _, status := range statuses {
+		o.Returns(status, handlers.ProblemContentType, handlers.Problem{})
+	}
+	return o
+}
+
+// Negotiated описывает обработчик с content negotiation: каждый JSON ответ
+// дополняется остальными media type (структурированные форматы получают ту же
+// модель, text/* - строку) и добавляется 406 для неподдерживаемого Accept
+func (o *Operation) Negotiated(mediaTypes ...string) *Operation {
+	var extra []ResponseSpec
+	for _, resp := range o.Responses {
+		if resp.ContentType != "application/json" {
+			continue
+		}
+		for _, mediaType := range mediaTypes {
+			if mediaType == resp.ContentType {
+				continue
+			}
+			var model interface{} = &Schema{Type: "string"}
+			if !strings.HasPrefix(mediaType, "text/") {
+				model = resp.Model
+			}
+			extra = append(extra, ResponseSpec{Status: resp.Status, Description: resp.Description, ContentType: mediaType, Model: model})
+		}
+	}
+	o.Responses = append(o.Responses, extra...)
+	return o.Problem(http.StatusNotAcceptable)
+}
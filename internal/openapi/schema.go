@@ -0,0 +1,248 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Schema - подмножество JSON Schema 2020-12, используемое в OpenAPI 3.1
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	// Nullable допускает null: type выводится как [type, "null"], $ref - через anyOf
+	Nullable bool `json:"-"`
+}
+
+// MarshalJSON выводит схему, записывая Nullable средствами JSON Schema 2020-12
+func (s Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	switch {
+	case !s.Nullable || (s.Ref == "" && s.Type == ""):
+		// Пустая схема и так допускает null
+		return json.Marshal(plain(s))
+	case s.Ref != "":
+		return json.Marshal(struct {
+			AnyOf       []*Schema `json:"anyOf"`
+			Description string    `json:"description,omitempty"`
+		}{AnyOf: []*Schema{{Ref: s.Ref}, {Type: "null"}}, Description: s.Description})
+	}
+	return json.Marshal(struct {
+		plain
+		Type []string `json:"type"`
+	}{plain: plain(s), Type: []string{s.Type, "null"}})
+}
+
+// refPrefix - префикс ссылок на компоненты документа
+const refPrefix = "#/components/schemas/"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// unsafeNameChars - символы, недопустимые в имени компонента OpenAPI
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// schemaRegistry строит схемы Go типов и собирает именованные структуры в components
+type schemaRegistry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+	owners  map[string]reflect.Type
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+		owners:  make(map[string]reflect.Type),
+	}
+}
+
+// componentName возвращает имя компонента для именованного типа.
+// Имя квалифицируется пакетом (models.InfoResponse), чтобы одноименные
+// типы из разных пакетов не перезаписывали друг друга. Если короткое имя
+// пакета тоже совпало, используется полный путь пакета.
+func (sr *schemaRegistry) componentName(t reflect.Type) string {
+	if name, ok := sr.names[t]; ok {
+		return name
+	}
+
+	name := unsafeNameChars.ReplaceAllString(path.Base(t.PkgPath())+"."+t.Name(), "_")
+	if owner, ok := sr.owners[name]; ok && owner != t {
+		name = unsafeNameChars.ReplaceAllString(t.PkgPath()+"."+t.Name(), "_")
+	}
+
+	sr.names[t] = name
+	sr.owners[name] = t
+	return name
+}
+
+// schemaFor возвращает схему значения. Именованные структуры регистрируются
+// в components, а вместо них возвращается $ref.
+func (sr *schemaRegistry) schemaFor(v interface{}) *Schema {
+	if v == nil {
+		return nil
+	}
+	if s, ok := v.(*Schema); ok {
+		return s
+	}
+	t := reflect.TypeOf(v)
+	// Сама модель ответа или запроса не бывает null, даже если передан указатель
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return sr.schemaForType(t)
+}
+
+// schemaForType возвращает схему типа. Указатели encoding/json выводит как null,
+// поэтому их схемы допускают null.
+func (sr *schemaRegistry) schemaForType(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		s := sr.schemaForType(t)
+		s.Nullable = true
+		return s
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json выводит []byte строкой base64, а nil - как null
+			return &Schema{Type: "string", ContentEncoding: "base64", Nullable: true}
+		}
+		return &Schema{Type: "array", Items: sr.schemaForType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: sr.schemaForType(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return sr.structSchema(t)
+		}
+		name := sr.componentName(t)
+		if _, ok := sr.schemas[name]; !ok {
+			// Резервируем имя до обхода полей, чтобы не зациклиться на рекурсивных типах
+			sr.schemas[name] = &Schema{}
+			*sr.schemas[name] = *sr.structSchema(t)
+		}
+		return &Schema{Ref: refPrefix + name}
+	}
+
+	// interface{} и прочие типы описываются пустой схемой (любое значение)
+	return &Schema{}
+}
+
+// structSchema строит схему структуры по json тегам.
+// Поля без omitempty считаются обязательными, лишние свойства запрещены.
+// Встроенные структуры без имени в json теге раскрываются в свойства
+// внешней, как это делает encoding/json; поля внешней структуры важнее.
+func (sr *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+
+	type embeddedStruct struct {
+		t   reflect.Type
+		ptr bool
+	}
+	var embedded []embeddedStruct
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+
+		if field.Anonymous && name == "" {
+			ft, ptr := field.Type, field.Type.Kind() == reflect.Ptr
+			if ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				// Неэкспортируемые встроенные структуры тоже раскрываются
+				embedded = append(embedded, embeddedStruct{t: ft, ptr: ptr})
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		omitempty := false
+		for _, opt := range parts[1:] {
+			if opt == "omitempty" {
+				omitempty = true
+			}
+		}
+
+		prop := sr.schemaForType(field.Type)
+		if desc := field.Tag.Get("doc"); desc != "" {
+			if prop.Ref != "" {
+				// В OpenAPI 3.1 соседние с $ref ключевые слова допустимы
+				prop = &Schema{Ref: prop.Ref, Description: desc, Nullable: prop.Nullable}
+			} else {
+				prop.Description = desc
+			}
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			for _, v := range strings.Split(enum, ",") {
+				prop.Enum = append(prop.Enum, v)
+			}
+		}
+
+		s.Properties[name] = prop
+		if !omitempty {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	for _, e := range embedded {
+		inner := sr.structSchema(e.t)
+		for _, name := range inner.Required {
+			// Поля nil указателя encoding/json не выводит, поэтому они не обязательны
+			if _, ok := s.Properties[name]; !ok && !e.ptr {
+				s.Required = append(s.Required, name)
+			}
+		}
+		for name, prop := range inner.Properties {
+			if _, ok := s.Properties[name]; !ok {
+				s.Properties[name] = prop
+			}
+		}
+	}
+
+	return s
+}
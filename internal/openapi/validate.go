@@ -0,0 +1,159 @@
+package openapi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"time"
+
+	"web-server-go-docker/internal/handlers"
+)
+
+// FieldError описывает несоответствие значения схеме
+type FieldError = handlers.FieldError
+
+// Validate проверяет значение, полученное из json.Unmarshal в interface{}, по схеме.
+// Возвращает пустой список, если значение соответствует схеме.
+func (d *Document) Validate(s *Schema, value interface{}) []FieldError {
+	var errs []FieldError
+	d.validate(s, value, "", &errs)
+	return errs
+}
+
+func (d *Document) validate(s *Schema, value interface{}, path string, errs *[]FieldError) {
+	if s != nil && s.Nullable && value == nil {
+		return
+	}
+	s = d.Resolve(s)
+	if s == nil {
+		return
+	}
+
+	fail := func(format string, args ...interface{}) {
+		field := path
+		if field == "" {
+			field = "$"
+		}
+		*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		fail("must be one of %v", s.Enum)
+		return
+	}
+
+	switch s.Type {
+	case "":
+		return
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				fail("must be an RFC 3339 date-time")
+			}
+		}
+		if s.ContentEncoding == "base64" {
+			if _, err := base64.StdEncoding.DecodeString(str); err != nil {
+				fail("must be base64 encoded")
+			}
+		}
+		if s.Pattern != "" {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(str) {
+				fail("must match pattern %s", s.Pattern)
+			}
+		}
+	case "integer", "number":
+		num, ok := value.(float64)
+		if !ok {
+			fail("must be a %s", s.Type)
+			return
+		}
+		if s.Type == "integer" && num != math.Trunc(num) {
+			fail("must be an integer")
+			return
+		}
+		if s.Minimum != nil && num < *s.Minimum {
+			fail("must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && num > *s.Maximum {
+			fail("must be <= %v", *s.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			if value == nil {
+				// nil слайсы Go сериализуются в null
+				return
+			}
+			fail("must be an array")
+			return
+		}
+		for i, item := range items {
+			d.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			if value == nil && s.Properties == nil {
+				// nil map Go сериализуется в null
+				return
+			}
+			fail("must be an object")
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				*errs = append(*errs, FieldError{Field: join(path, name), Message: "is required"})
+			}
+		}
+
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if prop, ok := s.Properties[k]; ok {
+				d.validate(prop, obj[k], join(path, k), errs)
+				continue
+			}
+			switch extra := s.AdditionalProperties.(type) {
+			case bool:
+				if !extra {
+					*errs = append(*errs, FieldError{Field: join(path, k), Message: "is not allowed"})
+				}
+			case *Schema:
+				d.validate(extra, obj[k], join(path, k), errs)
+			}
+		}
+	}
+}
+
+// join формирует путь к вложенному полю
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// inEnum проверяет вхождение значения в список допустимых
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
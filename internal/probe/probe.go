@@ -0,0 +1,114 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Options содержит параметры проверки
+type Options struct {
+	// URL проверяемого endpoint. При использовании SocketPath хост в URL игнорируется.
+	URL string
+	// SocketPath - путь к Unix сокету сервера (опционально)
+	SocketPath string
+	// Timeout - общий таймаут проверки
+	Timeout time.Duration
+	// CAFile - PEM файл с доверенными CA для https
+	CAFile string
+	// ServerName переопределяет имя сервера для проверки TLS сертификата
+	ServerName string
+	// InsecureSkipVerify отключает проверку TLS сертификата
+	InsecureSkipVerify bool
+}
+
+// Result содержит результат успешной проверки
+type Result struct {
+	StatusCode int
+	Status     string
+	Duration   time.Duration
+}
+
+// Run выполняет GET запрос к endpoint и возвращает ошибку, если ответ не 2xx
+func Run(ctx context.Context, opts Options) (*Result, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 3 * time.Second
+	}
+
+	transport, err := newTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer transport.CloseIdleConnections()
+
+	client := &http.Client{Transport: transport, Timeout: opts.Timeout}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, opts.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid probe URL: %w", err)
+	}
+	req.Header.Set("User-Agent", "web-server-healthcheck")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Дочитываем тело, чтобы корректно закрыть соединение
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	result := &Result{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Duration:   time.Since(start),
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return result, fmt.Errorf("%s returned %s", opts.URL, resp.Status)
+	}
+
+	return result, nil
+}
+
+// newTransport создает transport с учетом Unix сокета и TLS настроек
+func newTransport(opts Options) (*http.Transport, error) {
+	transport := &http.Transport{
+		DisableKeepAlives: true,
+	}
+
+	if opts.SocketPath != "" {
+		dialer := &net.Dialer{}
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", opts.SocketPath)
+		}
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify, // #nosec G402 -- явно запрошено флагом
+	}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
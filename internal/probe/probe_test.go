@@ -0,0 +1,94 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunHTTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"ready", http.StatusOK, false},
+		{"not ready", http.StatusServiceUnavailable, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer ts.Close()
+
+			result, err := Run(context.Background(), Options{URL: ts.URL + "/ready", Timeout: time.Second})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result == nil || result.StatusCode != tt.status {
+				t.Errorf("expected status %d in result, got %+v", tt.status, result)
+			}
+		})
+	}
+}
+
+func TestRunUnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "probe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "server.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets not available: %v", err)
+	}
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ready" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	if _, err := Run(context.Background(), Options{URL: "http://localhost/ready", SocketPath: socket}); err != nil {
+		t.Errorf("expected probe over unix socket to succeed: %v", err)
+	}
+}
+
+func TestRunTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	if _, err := Run(context.Background(), Options{URL: ts.URL}); err == nil {
+		t.Error("expected certificate verification error for self-signed server")
+	}
+
+	if _, err := Run(context.Background(), Options{URL: ts.URL, InsecureSkipVerify: true}); err != nil {
+		t.Errorf("expected probe with InsecureSkipVerify to succeed: %v", err)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	if _, err := Run(context.Background(), Options{URL: ts.URL, Timeout: 20 * time.Millisecond}); err == nil {
+		t.Error("expected timeout error")
+	}
+}
@@ -0,0 +1,51 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header - HTTP заголовок, в котором передается идентификатор запроса
+const Header = "X-Request-ID"
+
+// maxLength ограничивает длину идентификатора, принятого от клиента
+const maxLength = 128
+
+type contextKey struct{}
+
+// New генерирует новый случайный идентификатор запроса
+func New() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// Valid проверяет, что идентификатор от клиента безопасно логировать и возвращать
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// WithContext возвращает контекст с идентификатором запроса
+func WithContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext возвращает идентификатор запроса из контекста или пустую строку
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,125 @@
+package router
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"web-server-go-docker/internal/handlers"
+)
+
+// Router - маршрутизатор с учетом HTTP методов поверх http.ServeMux.
+// Сопоставление путей выполняет ServeMux (паттерны Go 1.22), а Router
+// выбирает обработчик по методу, автоматически обслуживает HEAD и OPTIONS
+// и отвечает 405 problem+json с заголовком Allow.
+type Router struct {
+	mux    *http.ServeMux
+	routes map[string]*route
+}
+
+// route хранит обработчики одного пути по методам
+type route struct {
+	path     string
+	handlers map[string]http.Handler
+}
+
+// New создает новый Router
+func New() *Router {
+	rt := &Router{
+		mux:    http.NewServeMux(),
+		routes: make(map[string]*route),
+	}
+	// Все, что не совпало ни с одним путем, получает 404 problem+json
+	rt.mux.HandleFunc("/", handlers.NotFound)
+	return rt
+}
+
+// Handle регистрирует обработчик для метода и пути.
+// Путь "/" совпадает только с корнем, а не со всеми путями.
+func (rt *Router) Handle(method, path string, h http.Handler) {
+	method = strings.ToUpper(method)
+
+	rte, ok := rt.routes[path]
+	if !ok {
+		rte = &route{path: path, handlers: make(map[string]http.Handler)}
+		rt.routes[path] = rte
+		rt.mux.Handle(muxPattern(path), rte.dispatcher())
+	}
+
+	if _, exists := rte.handlers[method]; exists {
+		panic("router: duplicate route " + method + " " + path)
+	}
+	rte.handlers[method] = h
+}
+
+// HandleFunc регистрирует функцию-обработчик для метода и пути
+func (rt *Router) HandleFunc(method, path string, h http.HandlerFunc) {
+	rt.Handle(method, path, h)
+}
+
+// Allowed возвращает список методов, разрешенных для пути
+func (rt *Router) Allowed(path string) []string {
+	rte, ok := rt.routes[path]
+	if !ok {
+		return nil
+	}
+	return rte.allowed()
+}
+
+// ServeHTTP реализует http.Handler
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.mux.ServeHTTP(w, r)
+}
+
+// muxPattern преобразует путь в паттерн ServeMux
+func muxPattern(path string) string {
+	if path == "/" {
+		return "/{$}"
+	}
+	return path
+}
+
+// dispatcher выбирает обработчик по методу запроса
+func (rte *route) dispatcher() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := rte.handlers[r.Method]; ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		// HEAD обслуживается GET обработчиком, net/http не отправит тело
+		if r.Method == http.MethodHead {
+			if h, ok := rte.handlers[http.MethodGet]; ok {
+				h.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		allowed := rte.allowed()
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		handlers.MethodNotAllowed(w, r, allowed...)
+	})
+}
+
+// allowed возвращает отсортированный список методов, включая неявные HEAD и OPTIONS
+func (rte *route) allowed() []string {
+	set := map[string]bool{http.MethodOptions: true}
+	for method := range rte.handlers {
+		set[method] = true
+	}
+	if set[http.MethodGet] {
+		set[http.MethodHead] = true
+	}
+
+	methods := make([]string, 0, len(set))
+	for method := range set {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
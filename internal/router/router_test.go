@@ -0,0 +1,94 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"web-server-go-docker/internal/handlers"
+)
+
+func newTestRouter() *Router {
+	rt := New()
+	rt.HandleFunc(http.MethodGet, "/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("root"))
+	})
+	rt.HandleFunc(http.MethodGet, "/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	rt.HandleFunc(http.MethodPost, "/items", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	return rt
+}
+
+func TestRouter(t *testing.T) {
+	rt := newTestRouter()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+		expectedAllow  string
+		expectedBody   string
+		problem        bool
+	}{
+		{"GET root", http.MethodGet, "/", http.StatusOK, "", "root", false},
+		{"GET health", http.MethodGet, "/health", http.StatusOK, "", "ok", false},
+		{"HEAD health uses GET handler", http.MethodHead, "/health", http.StatusOK, "", "", false},
+		{"OPTIONS health", http.MethodOptions, "/health", http.StatusNoContent, "GET, HEAD, OPTIONS", "", false},
+		{"POST health not allowed", http.MethodPost, "/health", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS", "", true},
+		{"DELETE root not allowed", http.MethodDelete, "/", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS", "", true},
+		{"GET on POST-only route", http.MethodGet, "/items", http.StatusMethodNotAllowed, "OPTIONS, POST", "", true},
+		{"POST items", http.MethodPost, "/items", http.StatusCreated, "", "", false},
+		{"unknown path", http.MethodGet, "/other", http.StatusNotFound, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			rt.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if allow := w.Header().Get("Allow"); allow != tt.expectedAllow {
+				t.Errorf("Expected Allow '%s', got '%s'", tt.expectedAllow, allow)
+			}
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body '%s', got '%s'", tt.expectedBody, w.Body.String())
+			}
+			if tt.problem && w.Header().Get("Content-Type") != handlers.ProblemContentType {
+				t.Errorf("Expected problem content type, got '%s'", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestRouterAllowed(t *testing.T) {
+	rt := newTestRouter()
+
+	if got := rt.Allowed("/missing"); got != nil {
+		t.Errorf("Expected nil for unknown path, got %v", got)
+	}
+
+	got := rt.Allowed("/health")
+	if len(got) != 3 || got[0] != http.MethodGet {
+		t.Errorf("Expected [GET HEAD OPTIONS], got %v", got)
+	}
+}
+
+func TestRouterDuplicatePanics(t *testing.T) {
+	rt := newTestRouter()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic on duplicate route")
+		}
+	}()
+
+	rt.HandleFunc(http.MethodGet, "/health", func(w http.ResponseWriter, r *http.Request) {})
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"web-server-go-docker/internal/config"
+	"web-server-go-docker/internal/handlers"
+	"web-server-go-docker/internal/metrics"
+)
+
+// Module - подключаемая часть приложения, построенного на этом сервере.
+// В Register модуль регистрирует маршруты, проверки готовности,
+// метрики и хуки остановки через методы Server.
+type Module interface {
+	Name() string
+	Register(s *Server) error
+}
+
+// HealthCheck - проверка зависимости, влияющая на /ready
+type HealthCheck = handlers.CheckFunc
+
+// ShutdownHook вызывается при остановке сервера после завершения HTTP запросов
+type ShutdownHook func(ctx context.Context) error
+
+// Register подключает модули к серверу. Должен вызываться до Start.
+func (s *Server) Register(modules ...Module) error {
+	for _, m := range modules {
+		if err := m.Register(s); err != nil {
+			return fmt.Errorf("module %s: %w", m.Name(), err)
+		}
+		s.modules = append(s.modules, m.Name())
+	}
+	return nil
+}
+
+// Modules возвращает имена подключенных модулей
+func (s *Server) Modules() []string {
+	return append([]string(nil), s.modules...)
+}
+
+// AddHealthCheck регистрирует проверку, выполняемую readiness endpoint
+func (s *Server) AddHealthCheck(name string, check HealthCheck) {
+	s.handler.AddCheck(name, check)
+}
+
+// OnShutdown регистрирует хук, вызываемый при остановке сервера.
+// Хуки выполняются в обратном порядке регистрации.
+func (s *Server) OnShutdown(hook ShutdownHook) {
+	s.shutdownHooks = append(s.shutdownHooks, hook)
+}
+
+// Metrics возвращает метрики сервера или nil, если они отключены.
+// Модули могут регистрировать свои collectors через Metrics().Register и
+// создавать метрики через Metrics().NewCounter и др.: для nil они проверяют
+// описание и ничего не записывают.
+func (s *Server) Metrics() *metrics.Metrics {
+	return s.metrics
+}
+
+// Handler возвращает корневой handler сервера: router вместе с глобальными middleware
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
+// RegisterEncoder добавляет формат ответа встроенных обработчиков (content negotiation
+// по Accept). Должен вызываться до Start.
+func (s *Server) RegisterEncoder(enc handlers.Encoder, aliases ...string) {
+	s.handler.RegisterEncoder(enc, aliases...)
+}
+
+// Config возвращает конфигурацию сервера
+func (s *Server) Config() *config.Config {
+	return s.config
+}
@@ -0,0 +1,276 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"web-server-go-docker/internal/apikey"
+	"web-server-go-docker/internal/handlers"
+	"web-server-go-docker/internal/middleware"
+	"web-server-go-docker/internal/models"
+	"web-server-go-docker/internal/openapi"
+	"web-server-go-docker/internal/stats"
+)
+
+// RouteInfo описывает зарегистрированный маршрут
+type RouteInfo struct {
+	Method      string
+	Path        string
+	Description string
+	Middleware  []string
+}
+
+// route - зарегистрированный маршрут с его собственными middleware
+type route struct {
+	info       RouteInfo
+	middleware []middleware.Middleware
+	operation  *openapi.Operation
+}
+
+// routeOptions содержит настройки регистрируемого маршрута
+type routeOptions struct {
+	description string
+	middleware  []middleware.Middleware
+	operation   *openapi.Operation
+
+	// API ключ и scopes, которые он должен иметь (WithAPIKey)
+	apiKey bool
+	scopes []string
+}
+
+// RouteOption настраивает маршрут или группу маршрутов
+type RouteOption func(*routeOptions)
+
+// WithDescription задает описание маршрута для вывода в логах и `routes`
+func WithDescription(description string) RouteOption {
+	return func(o *routeOptions) {
+		o.description = description
+	}
+}
+
+// WithOperation описывает маршрут для OpenAPI документа: параметры, тело и ответы
+func WithOperation(op *openapi.Operation) RouteOption {
+	return func(o *routeOptions) {
+		o.operation = op
+	}
+}
+
+// WithAPIKey требует для маршрута (или группы) API ключ со всеми scopes.
+// Scopes группы и маршрута складываются. Проверка ключа выполняется первой
+// среди middleware маршрута; без API_KEYS_FILE все запросы отклоняются.
+func WithAPIKey(scopes ...string) RouteOption {
+	for _, scope := range scopes {
+		if !apikey.ValidScope(scope) {
+			panic(fmt.Sprintf("server: invalid API key scope %q", scope))
+		}
+	}
+	return func(o *routeOptions) {
+		o.apiKey = true
+		o.scopes = append(o.scopes, scopes...)
+	}
+}
+
+// WithMiddleware добавляет middleware, применяемые только к этому маршруту (или группе).
+// Они выполняются после глобальной цепочки, в порядке перечисления.
+func WithMiddleware(mws ...middleware.Middleware) RouteOption {
+	return func(o *routeOptions) {
+		o.middleware = append(o.middleware, mws...)
+	}
+}
+
+// Handle регистрирует обработчик для метода и пути.
+// Маршруты должны регистрироваться до вызова Start.
+func (s *Server) Handle(method, path string, h http.Handler, opts ...RouteOption) {
+	var o routeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.apiKey {
+		auth := middleware.NewAPIKeyMiddleware(s.apiKeys, o.scopes...)
+		o.middleware = append([]middleware.Middleware{auth}, o.middleware...)
+		if o.operation != nil {
+			// Копия: одна операция может описывать несколько маршрутов, в том числе без ключа
+			o.operation = o.operation.Clone()
+			for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+				if _, ok := o.operation.Response(status); !ok {
+					o.operation.Problem(status)
+				}
+			}
+		}
+	}
+
+	// Проверка по описанию маршрута выполняется последней, непосредственно перед обработчиком
+	requests, responses := s.config.OpenAPI.ValidateRequests, s.config.OpenAPI.ValidateResponses
+	if (requests || responses) && o.operation != nil {
+		o.middleware = append(o.middleware, openapi.NewValidator(method, path, o.operation, requests, responses))
+	}
+
+	if len(o.middleware) > 0 {
+		h = middleware.Chain(o.middleware...)(h)
+	}
+
+	// Статистика группирует запросы по шаблону маршрута, а не по URL
+	h = routeMarker(strings.ToUpper(method)+" "+path, h)
+
+	s.router.Handle(method, path, h)
+	s.routes = append(s.routes, route{
+		info: RouteInfo{
+			Method:      strings.ToUpper(method),
+			Path:        path,
+			Description: o.description,
+		},
+		middleware: o.middleware,
+		operation:  o.operation,
+	})
+}
+
+// routeMarker сообщает stats middleware имя маршрута, которым обработан запрос
+func routeMarker(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stats.SetRoute(r.Context(), route)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HandleFunc регистрирует функцию-обработчик для метода и пути
+func (s *Server) HandleFunc(method, path string, h http.HandlerFunc, opts ...RouteOption) {
+	s.Handle(method, path, h, opts...)
+}
+
+// Group создает группу маршрутов с общим префиксом и настройками
+func (s *Server) Group(prefix string, opts ...RouteOption) *Group {
+	return &Group{
+		server: s,
+		prefix: strings.TrimSuffix(prefix, "/"),
+		opts:   opts,
+	}
+}
+
+// Routes возвращает зарегистрированные маршруты вместе с эффективной цепочкой middleware
+func (s *Server) Routes() []RouteInfo {
+	global := make([]string, 0, len(s.middlewares))
+	for _, m := range s.middlewares {
+		global = append(global, middleware.Name(m))
+	}
+
+	routes := make([]RouteInfo, len(s.routes))
+	for i, r := range s.routes {
+		info := r.info
+		info.Middleware = append([]string(nil), global...)
+		for _, m := range r.middleware {
+			info.Middleware = append(info.Middleware, middleware.Name(m))
+		}
+		routes[i] = info
+	}
+	return routes
+}
+
+// RouteChain возвращает эффективную цепочку middleware маршрута
+// (глобальные, затем групп, затем самого маршрута) или false, если маршрута нет
+func (s *Server) RouteChain(method, path string) ([]string, bool) {
+	for _, info := range s.Routes() {
+		if info.Method == strings.ToUpper(method) && info.Path == path {
+			return info.Middleware, true
+		}
+	}
+	return nil, false
+}
+
+// OpenAPI строит OpenAPI документ по зарегистрированным маршрутам.
+// Маршруты без WithOperation описываются только кратким описанием.
+func (s *Server) OpenAPI() *openapi.Document {
+	doc := openapi.NewDocument(openapi.Info{
+		Title:       "Go Web Server",
+		Version:     s.config.App.Version,
+		Description: "HTTP API generated from registered routes",
+	})
+
+	for _, r := range s.routes {
+		op := openapi.NewOperation(r.info.Description)
+		if r.operation != nil {
+			described := *r.operation
+			if described.Summary == "" {
+				described.Summary = r.info.Description
+			}
+			op = &described
+		}
+		doc.Add(r.info.Method, r.info.Path, op)
+	}
+
+	return doc
+}
+
+// debugRoutes отдает список маршрутов с цепочками middleware (только в development)
+func (s *Server) debugRoutes(w http.ResponseWriter, r *http.Request) {
+	routes := s.Routes()
+	response := models.RoutesResponse{Routes: make([]models.RouteResponse, 0, len(routes))}
+	for _, info := range routes {
+		response.Routes = append(response.Routes, models.RouteResponse{
+			Method:      info.Method,
+			Path:        info.Path,
+			Description: info.Description,
+			Middleware:  info.Middleware,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		handlers.InternalError(w, r, fmt.Errorf("encoding routes response: %w", err))
+	}
+}
+
+// Group - набор маршрутов с общим префиксом и middleware
+type Group struct {
+	server *Server
+	prefix string
+	opts   []RouteOption
+}
+
+// Handle регистрирует обработчик внутри группы.
+// Настройки группы применяются раньше настроек маршрута.
+func (g *Group) Handle(method, path string, h http.Handler, opts ...RouteOption) {
+	all := append(append([]RouteOption(nil), g.opts...), opts...)
+	g.server.Handle(method, g.path(path), h, all...)
+}
+
+// HandleFunc регистрирует функцию-обработчик внутри группы
+func (g *Group) HandleFunc(method, path string, h http.HandlerFunc, opts ...RouteOption) {
+	g.Handle(method, path, h, opts...)
+}
+
+// Use добавляет middleware в стек группы. Они применяются к маршрутам и
+// вложенным группам, зарегистрированным после вызова Use.
+func (g *Group) Use(mws ...middleware.Middleware) {
+	g.opts = append(g.opts, WithMiddleware(mws...))
+}
+
+// Group создает вложенную группу, наследующую префикс и настройки родителя
+func (g *Group) Group(prefix string, opts ...RouteOption) *Group {
+	return &Group{
+		server: g.server,
+		prefix: g.path(strings.TrimSuffix(prefix, "/")),
+		opts:   append(append([]RouteOption(nil), g.opts...), opts...),
+	}
+}
+
+// Prefix возвращает полный префикс группы
+func (g *Group) Prefix() string {
+	return g.prefix
+}
+
+// path объединяет префикс группы и путь маршрута
+func (g *Group) path(path string) string {
+	if path == "" || path == "/" {
+		if g.prefix == "" {
+			return "/"
+		}
+		return g.prefix
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return g.prefix + path
+}
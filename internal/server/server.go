@@ -3,18 +3,29 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"web-server-go-docker/internal/apikey"
+	"web-server-go-docker/internal/buildinfo"
 	"web-server-go-docker/internal/config"
+	"web-server-go-docker/internal/dashboard"
 	"web-server-go-docker/internal/handlers"
 	"web-server-go-docker/internal/metrics"
 	"web-server-go-docker/internal/middleware"
+	"web-server-go-docker/internal/models"
+	"web-server-go-docker/internal/openapi"
+	"web-server-go-docker/internal/router"
+	"web-server-go-docker/internal/slo"
+	"web-server-go-docker/internal/stats"
 )
 
 // Server представляет HTTP сервер с зависимостями
@@ -22,54 +33,256 @@ type Server struct {
 	config       *config.Config
 	metrics      *metrics.Metrics
 	handler      *handlers.Handler
-	requestCount int
+	stats        *stats.Stats
+	history      *stats.History
 	httpServer   *http.Server
+	router       *router.Router
+	routes       []route
+	middlewares  []middleware.Middleware
+	slo          *slo.Tracker
+	scrapeAuth   *middleware.ScrapeAuthMiddleware
+	apiKeys     *apikey.Store
+
+	modules       []string
+	shutdownHooks []ShutdownHook
+	// shutdownTimeout ограничивает ожидание текущих запросов и, отдельно, хуки остановки
+	shutdownTimeout time.Duration
 }
 
 // New создает новый сервер с зависимостями
 func New(cfg *config.Config) (*Server, error) {
+	st := stats.New()
+
+	// Ключи загружаются до регистрации маршрутов: WithAPIKey ссылается на хранилище
+	keys, err := apikey.NewStore(cfg.APIKeys.File)
+	if err != nil {
+		return nil, err
+	}
+
 	var m *metrics.Metrics
 	if cfg.Metrics.Enabled {
-		m = metrics.New()
+		opts, err := metricsOptions(cfg, st)
+		if err != nil {
+			return nil, err
+		}
+		m = metrics.New(opts...)
 	}
 
 	s := &Server{
-		config:       cfg,
-		metrics:      m,
-		requestCount: 0,
+		config:          cfg,
+		metrics:         m,
+		stats:           st,
+		apiKeys:         keys,
+		shutdownTimeout: 10 * time.Second,
+	}
+
+	// SLO считаются только вместе с метриками, это проверяет config.Validate
+	if cfg.SLO.File != "" && m != nil {
+		spec, err := slo.Load(cfg.SLO.File)
+		if err != nil {
+			m.Close()
+			return nil, err
+		}
+		s.slo = slo.NewTracker(spec)
+		if err := m.Register(s.slo); err != nil {
+			m.Close()
+			return nil, fmt.Errorf("failed to register SLO metrics: %w", err)
+		}
+	}
+
+	if m != nil && scrapeAuthEnabled(cfg.Metrics) {
+		auth, err := middleware.NewScrapeAuthMiddleware(middleware.ScrapeAuthOptions{
+			Users:           cfg.Metrics.ScrapeUsers,
+			BearerToken:     cfg.Metrics.ScrapeBearerToken,
+			AllowedNetworks: cfg.Metrics.ScrapeAllowedNetworks,
+		})
+		if err != nil {
+			m.Close()
+			return nil, err
+		}
+		s.scrapeAuth = auth
 	}
 
-	s.handler = handlers.New(cfg, m, &s.requestCount)
+	s.history = stats.NewHistory(s.stats, cfg.Metrics.HistoryResolution, cfg.Metrics.HistoryRetention)
+	s.handler = handlers.New(cfg, m, s.stats)
+	s.handler.SetHistory(s.history)
+	s.router = router.New()
 	s.setupRoutes()
 
 	return s, nil
 }
 
+// scrapeAuthEnabled сообщает, ограничен ли доступ к пути метрик
+func scrapeAuthEnabled(c config.MetricsConfig) bool {
+	return len(c.ScrapeUsers) > 0 || c.ScrapeBearerToken != "" || len(c.ScrapeAllowedNetworks) > 0
+}
+
+// metricsOptions переводит MetricsConfig в опции metrics.New
+func metricsOptions(c *config.Config, st *stats.Stats) ([]metrics.Option, error) {
+	cfg := c.Metrics
+
+	opts := []metrics.Option{
+		metrics.WithNamespace(cfg.Namespace),
+		metrics.WithSeriesLimit(cfg.MaxSeries),
+	}
+	for name, buckets := range cfg.Buckets {
+		opts = append(opts, metrics.WithBuckets(name, buckets))
+	}
+	if cfg.NativeHistograms {
+		opts = append(opts, metrics.WithNativeHistograms(cfg.NativeHistogramBucketFactor, cfg.NativeHistogramMaxBuckets))
+	}
+	if cfg.Quantiles {
+		opts = append(opts, metrics.WithQuantiles(st))
+	}
+	if cfg.OTLPEndpoint != "" {
+		exporter, err := metrics.NewOTLPExporter(metrics.OTLPOptions{
+			Endpoint:    cfg.OTLPEndpoint,
+			Headers:     cfg.OTLPHeaders,
+			Interval:    cfg.OTLPInterval,
+			Temporality: cfg.OTLPTemporality,
+			Resource: map[string]string{
+				"service.name":           cfg.OTLPServiceName,
+				"service.version":        c.App.Version,
+				"deployment.environment": c.App.Environment,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, metrics.WithOTLP(exporter))
+	}
+	if cfg.PushgatewayURL != "" {
+		grouping := map[string]string{
+			"environment": c.App.Environment,
+			"instance":    cfg.PushgatewayInstance,
+		}
+		for name, value := range cfg.PushgatewayGrouping {
+			grouping[name] = value
+		}
+		pusher, err := metrics.NewPushgateway(metrics.PushOptions{
+			URL:      cfg.PushgatewayURL,
+			Job:      cfg.PushgatewayJob,
+			Grouping: grouping,
+			Interval: cfg.PushgatewayInterval,
+			Retries:  cfg.PushgatewayRetries,
+		})
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, metrics.WithPushgateway(pusher))
+	}
+	// StatsD подключается последним: остальные опции не открывают соединений
+	if cfg.StatsDAddress != "" {
+		client, err := metrics.NewStatsD(metrics.StatsDOptions{
+			Address:       cfg.StatsDAddress,
+			Flavor:        cfg.StatsDFlavor,
+			Prefix:        cfg.StatsDPrefix,
+			Tags:          cfg.StatsDTags,
+			FlushInterval: cfg.StatsDFlushInterval,
+		})
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, metrics.WithStatsD(client))
+	}
+	return opts, nil
+}
+
 // setupRoutes настраивает маршруты и middleware
 func (s *Server) setupRoutes() {
-	mux := http.NewServeMux()
-	
-	// Регистрируем маршруты
-	mux.HandleFunc("/", s.handler.Info)
-	mux.HandleFunc("/health", s.handler.Health)
-	mux.HandleFunc("/metrics", s.handler.Metrics)
-	
+	// Регистрируем встроенные маршруты. Обработчики из handlers выбирают формат
+	// ответа по Accept, поэтому в документе перечислены все поддерживаемые форматы.
+	formats := s.handler.MediaTypes()
+	s.HandleFunc(http.MethodGet, "/", s.handler.Info, WithDescription("Server info"),
+		WithOperation(openapi.NewOperation("").WithTags("info").JSON(http.StatusOK, models.InfoResponse{}).Negotiated(formats...)))
+	s.HandleFunc(http.MethodGet, "/health", s.handler.Health, WithDescription("Health check"),
+		WithOperation(openapi.NewOperation("").WithTags("health").JSON(http.StatusOK, models.HealthResponse{}).Negotiated(formats...)))
+	s.HandleFunc(http.MethodGet, "/ready", s.handler.Ready, WithDescription("Readiness check"),
+		WithOperation(openapi.NewOperation("").WithTags("health").
+			JSON(http.StatusOK, models.HealthResponse{}).
+			JSON(http.StatusServiceUnavailable, models.HealthResponse{}).
+			Negotiated(formats...)))
+	s.HandleFunc(http.MethodGet, "/metrics", s.handler.Metrics, WithDescription("Server metrics (JSON)"),
+		WithOperation(openapi.NewOperation("").WithTags("metrics").JSON(http.StatusOK, models.MetricsResponse{}).Negotiated(formats...)))
+	s.HandleFunc(http.MethodGet, "/metrics/history", s.handler.MetricsHistory,
+		WithDescription("Server stats history (JSON)"),
+		WithOperation(openapi.NewOperation("").WithTags("metrics").
+			Query("window", "", false, "How far back to look, e.g. 15m; between the history resolution and retention (default: whole history)").
+			Query("resolution", "", false, "Step between points, e.g. 1m (rounded up to the history interval)").
+			JSON(http.StatusOK, models.MetricsHistoryResponse{}).
+			Negotiated(formats...).
+			Problem(http.StatusBadRequest)))
+	s.HandleFunc(http.MethodGet, "/metrics/stream", s.handler.MetricsStream,
+		WithDescription("Live JSON metrics (Server-Sent Events)"),
+		WithOperation(openapi.NewOperation("").WithTags("metrics").
+			WithDescription("Sends a `metrics` event with the /metrics payload every interval. "+
+				"Reconnecting with Last-Event-ID first replays missed `history` events.").
+			Query("interval", "", false, "Time between events, 1s to 1m").
+			Header("Last-Event-ID", "", false, "Resume after this event ID (Unix milliseconds)").
+			Returns(http.StatusOK, "text/event-stream", &openapi.Schema{Type: "string"}).
+			Problem(http.StatusBadRequest, http.StatusServiceUnavailable)))
+	s.HandleFunc(http.MethodGet, "/version", s.handler.Version, WithDescription("Build information"),
+		WithOperation(openapi.NewOperation("").WithTags("info").JSON(http.StatusOK, models.VersionResponse{}).Negotiated(formats...)))
+
 	if s.config.Metrics.Enabled && s.metrics != nil {
-		mux.HandleFunc(s.config.Metrics.Path, s.handler.PrometheusMetrics)
+		operation := openapi.NewOperation("").WithTags("metrics").
+			Returns(http.StatusOK, "text/plain", &openapi.Schema{Type: "string"})
+		opts := []RouteOption{WithDescription("Prometheus metrics"), WithOperation(operation)}
+		if s.scrapeAuth != nil {
+			operation.Problem(http.StatusUnauthorized, http.StatusForbidden)
+			opts = append(opts, WithMiddleware(s.scrapeAuth))
+		}
+		s.HandleFunc(http.MethodGet, s.config.Metrics.Path, s.handler.PrometheusMetrics, opts...)
+	}
+
+	if s.config.OpenAPI.Enabled {
+		s.HandleFunc(http.MethodGet, "/openapi.json", openapi.Handler(s.OpenAPI),
+			WithDescription("OpenAPI 3.1 document"),
+			WithOperation(openapi.NewOperation("").WithTags("docs").
+				Returns(http.StatusOK, "application/json", &openapi.Schema{Type: "object"})))
+
+		if s.config.OpenAPI.UIEnabled {
+			script := openapi.UIScript{URL: s.config.OpenAPI.UIScriptURL, Integrity: s.config.OpenAPI.UIScriptIntegrity}
+			if script.Integrity == "" {
+				log.Printf("API docs page loads Redoc without an integrity check, set OPENAPI_UI_SCRIPT_INTEGRITY (make redoc-sri)")
+			}
+			s.HandleFunc(http.MethodGet, "/docs", openapi.UIHandler("/openapi.json", script),
+				WithDescription("API documentation (Redoc)"),
+				WithOperation(openapi.NewOperation("").WithTags("docs").
+					Returns(http.StatusOK, "text/html", &openapi.Schema{Type: "string"})))
+		}
+	}
+
+	if s.config.Dashboard.Enabled {
+		s.setupDashboard()
+	}
+
+	if s.config.IsDevelopment() {
+		s.HandleFunc(http.MethodGet, "/debug/routes", s.debugRoutes,
+			WithDescription("Routes with effective middleware chains (development only)"),
+			WithOperation(openapi.NewOperation("").WithTags("debug").JSON(http.StatusOK, models.RoutesResponse{})))
 	}
 
 	// Настраиваем middleware
-	var middlewares []middleware.Middleware
-	
-	middlewares = append(middlewares, middleware.NewSecurityMiddleware())
-	middlewares = append(middlewares, middleware.NewRequestCounterMiddleware(&s.requestCount))
-	
+	s.middlewares = append(s.middlewares, middleware.NewRequestIDMiddleware())
+	s.middlewares = append(s.middlewares, middleware.NewTraceContextMiddleware())
+	s.middlewares = append(s.middlewares, middleware.NewSecurityMiddleware())
+	s.middlewares = append(s.middlewares, middleware.NewStatsMiddleware(s.stats))
+
+	if s.slo != nil {
+		s.middlewares = append(s.middlewares, middleware.NewSLOMiddleware(s.slo))
+	}
+
 	if s.metrics != nil {
-		middlewares = append(middlewares, middleware.NewLoggingMiddleware(s.metrics))
+		s.middlewares = append(s.middlewares, middleware.NewLoggingMiddleware(s.metrics))
 	}
 
-	// Применяем middleware chain
-	handler := middleware.Chain(middlewares...)(mux)
+	// Recovery самый внутренний, чтобы logging видел итоговый статус 500
+	s.middlewares = append(s.middlewares, middleware.NewRecoveryMiddleware())
+
+	// Применяем middleware chain. Маршруты, добавленные позже через Handle,
+	// попадают в тот же router и тоже проходят через эту цепочку.
+	handler := middleware.Chain(s.middlewares...)(s.router)
 
 	s.httpServer = &http.Server{
 		Addr:         fmt.Sprintf(":%s", s.config.Server.Port),
@@ -78,32 +291,120 @@ func (s *Server) setupRoutes() {
 		WriteTimeout: s.config.Server.WriteTimeout,
 		IdleTimeout:  s.config.Server.IdleTimeout,
 	}
+	// Shutdown ждет завершения активных запросов, поэтому потоки метрик закрываются явно
+	s.httpServer.RegisterOnShutdown(s.handler.CloseStreams)
+}
+
+// setupDashboard регистрирует страницу состояния под basic auth администратора
+func (s *Server) setupDashboard() {
+	base := strings.TrimSuffix(s.config.Dashboard.Path, "/")
+	auth := middleware.NewBasicAuthMiddleware("dashboard", s.config.Admin.Username, s.config.Admin.Password)
+	group := s.Group(base, WithMiddleware(auth))
+
+	group.HandleFunc(http.MethodGet, "/", dashboard.IndexHandler(dashboard.Page{
+		Base:        base,
+		Environment: s.config.App.Environment,
+		StreamURL:   "/metrics/stream",
+		HistoryURL:  "/metrics/history",
+		ReadyURL:    "/ready",
+		VersionURL:  "/version",
+		ErrorsURL:   base + "/errors",
+	}),
+		WithDescription("Status dashboard (admin)"),
+		WithOperation(openapi.NewOperation("").WithTags("dashboard").
+			Returns(http.StatusOK, "text/html", &openapi.Schema{Type: "string"}).
+			Problem(http.StatusUnauthorized)))
+	group.HandleFunc(http.MethodGet, "/errors", s.handler.RecentErrors,
+		WithDescription("Recent requests answered with 5xx (admin)"),
+		WithOperation(openapi.NewOperation("").WithTags("dashboard").
+			JSON(http.StatusOK, models.RecentErrorsResponse{}).
+			Problem(http.StatusUnauthorized)))
+	group.Handle(http.MethodGet, "/assets/{file}", dashboard.AssetsHandler(base+"/assets/"),
+		WithDescription("Status dashboard scripts and styles (admin)"),
+		WithOperation(openapi.NewOperation("").WithTags("dashboard").
+			Returns(http.StatusOK, "text/plain", &openapi.Schema{Type: "string"}).
+			Problem(http.StatusUnauthorized, http.StatusNotFound)))
 }
 
 // Start запускает сервер
 func (s *Server) Start() error {
+	// Сокеты открываются до запуска обработки: ошибка привязки возвращается из Start,
+	// а готовность выставляется, только когда сервер уже принимает соединения
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("could not listen on port %s: %w", s.config.Server.Port, err)
+	}
+
+	// Дополнительный listener на Unix сокете (например, для healthcheck без сети)
+	var unixLn net.Listener
+	if s.config.Server.SocketPath != "" {
+		unixLn, err = listenUnix(s.config.Server.SocketPath)
+		if err != nil {
+			ln.Close()
+			return err
+		}
+	}
+
 	// Канал для graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
+	// SIGHUP перечитывает API ключи без перезапуска, например при ротации
+	if s.config.APIKeys.File != "" {
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		done := make(chan struct{})
+		defer func() {
+			signal.Stop(reload)
+			close(done)
+		}()
+
+		go func() {
+			for {
+				select {
+				case <-reload:
+					s.ReloadAPIKeys()
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
 	// Запуск сервера в отдельной горутине
 	go func() {
 		log.Printf("Starting server on port %s", s.config.Server.Port)
 		log.Printf("Environment: %s", s.config.App.Environment)
+		info := buildinfo.Get()
+		log.Printf("Version: %s (commit %s, dirty=%t, %s)", info.Version, info.ShortCommit(), info.Dirty, info.GoVersion)
+		if len(s.modules) > 0 {
+			log.Printf("Modules: %s", strings.Join(s.modules, ", "))
+		}
+		if s.config.APIKeys.File != "" {
+			log.Printf("API keys: %d loaded from %s (reload with SIGHUP)", s.apiKeys.Len(), s.config.APIKeys.File)
+		}
 		log.Printf("Available endpoints:")
-		log.Printf("  GET / - Server info")
-		log.Printf("  GET /health - Health check")
-		log.Printf("  GET /metrics - Server metrics (JSON)")
-		
-		if s.config.Metrics.Enabled {
-			log.Printf("  GET %s - Prometheus metrics", s.config.Metrics.Path)
+		for _, route := range s.Routes() {
+			log.Printf("  %s %s - %s", route.Method, route.Path, route.Description)
 		}
 
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Could not listen on port %s: %v", s.config.Server.Port, err)
+		if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Could not serve on port %s: %v", s.config.Server.Port, err)
 		}
 	}()
 
+	if unixLn != nil {
+		go func() {
+			log.Printf("Listening on unix socket %s", s.config.Server.SocketPath)
+			if err := s.httpServer.Serve(unixLn); err != nil && err != http.ErrServerClosed {
+				log.Printf("Unix socket listener error: %v", err)
+			}
+		}()
+	}
+
+	s.history.Start()
+	s.handler.SetReady(true)
+
 	// Ожидание сигнала завершения
 	<-quit
 	log.Println("Shutting down server...")
@@ -111,21 +412,98 @@ func (s *Server) Start() error {
 	return s.Shutdown()
 }
 
+// ReloadAPIKeys перечитывает API_KEYS_FILE. При ошибке остаются прежние ключи.
+func (s *Server) ReloadAPIKeys() error {
+	if err := s.apiKeys.Reload(); err != nil {
+		log.Printf("Failed to reload API keys, keeping %d loaded keys: %v", s.apiKeys.Len(), err)
+		return err
+	}
+	log.Printf("Reloaded %d API keys from %s", s.apiKeys.Len(), s.config.APIKeys.File)
+	return nil
+}
+
 // Shutdown выполняет graceful shutdown сервера
 func (s *Server) Shutdown() error {
-	// Graceful shutdown с таймаутом 10 секунд
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Сначала снимаем готовность, чтобы probe перестали направлять трафик
+	s.handler.SetReady(false)
+
+	// Graceful shutdown с таймаутом. Если запросы не завершились, соединения
+	// закрываются принудительно, а остальные шаги остановки все равно выполняются.
+	var errs []error
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
 	defer cancel()
 
 	if err := s.httpServer.Shutdown(ctx); err != nil {
-		return fmt.Errorf("server forced to shutdown: %w", err)
+		s.httpServer.Close()
+		errs = append(errs, fmt.Errorf("server forced to shutdown: %w", err))
+	}
+	s.history.Stop()
+
+	// Остаток метрик отправляется после последних запросов, в том числе после
+	// принудительной остановки
+	if s.metrics != nil {
+		if err := s.metrics.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush metrics: %w", err))
+		}
+	}
+
+	// Хуки модулей выполняются в обратном порядке регистрации. Контекст свой:
+	// ctx мог истечь, пока сервер ждал запросы.
+	hookCtx, cancelHooks := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancelHooks()
+
+	var hookErrs []error
+	for i := len(s.shutdownHooks) - 1; i >= 0; i-- {
+		if err := s.shutdownHooks[i](hookCtx); err != nil {
+			hookErrs = append(hookErrs, err)
+		}
+	}
+	if err := errors.Join(hookErrs...); err != nil {
+		errs = append(errs, fmt.Errorf("shutdown hooks failed: %w", err))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
 	}
 
 	log.Println("Server exited gracefully")
 	return nil
 }
 
+// listenUnix создает listener на Unix сокете, удаляя оставшийся от прошлого запуска сокет.
+// Удаляется только сокет, к которому никто не подключен; другие файлы
+// и сокет работающего экземпляра не трогаются.
+func listenUnix(path string) (net.Listener, error) {
+	info, err := os.Lstat(path)
+	switch {
+	case err == nil:
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("socket path %s exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("socket %s is in use by another process", path)
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to stat socket %s: %w", path, err)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on unix socket %s: %w", path, err)
+	}
+
+	return ln, nil
+}
+
 // GetRequestCount возвращает количество обработанных запросов
 func (s *Server) GetRequestCount() int {
-	return s.requestCount
+	return int(s.stats.Total())
+}
+
+// Stats возвращает статистику запросов сервера
+func (s *Server) Stats() *stats.Stats {
+	return s.stats
 }
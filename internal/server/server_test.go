@@ -0,0 +1,876 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"web-server-go-docker/internal/config"
+	"web-server-go-docker/internal/middleware"
+	"web-server-go-docker/internal/openapi"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Port: "0"},
+		App:    config.AppConfig{Environment: "test", Version: "1.0.0-test"},
+	}
+
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	return s
+}
+
+func serve(s *Server, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func headerMiddleware(name, value string) middleware.Middleware {
+	return middleware.NewFunc(name, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Test", value)
+			next.ServeHTTP(w, r)
+		})
+	})
+}
+
+func TestServer_HandleWithGroupsAndMiddleware(t *testing.T) {
+	s := newTestServer(t)
+
+	api := s.Group("/api", WithMiddleware(headerMiddleware("api", "group")))
+	api.HandleFunc(http.MethodGet, "/items", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("items"))
+	}, WithMiddleware(headerMiddleware("items", "route")), WithDescription("List items"))
+
+	v2 := api.Group("/v2/")
+	v2.HandleFunc(http.MethodPost, "/items", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	w := serve(s, http.MethodGet, "/api/items")
+	if w.Code != http.StatusOK || w.Body.String() != "items" {
+		t.Fatalf("Expected 200 'items', got %d '%s'", w.Code, w.Body.String())
+	}
+	if got := strings.Join(w.Header().Values("X-Test"), ","); got != "group,route" {
+		t.Errorf("Expected group middleware before route middleware, got '%s'", got)
+	}
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Error("Expected global middleware to apply to custom routes")
+	}
+
+	if w := serve(s, http.MethodPost, "/api/v2/items"); w.Code != http.StatusCreated {
+		t.Errorf("Expected 201 for nested group route, got %d", w.Code)
+	}
+
+	var found bool
+	for _, route := range s.Routes() {
+		if route.Path != "/api/items" {
+			continue
+		}
+		found = true
+		chain := strings.Join(route.Middleware, " -> ")
+		if !strings.HasSuffix(chain, "recovery -> api -> items") {
+			t.Errorf("Unexpected middleware chain: %s", chain)
+		}
+		if route.Description != "List items" {
+			t.Errorf("Unexpected description: %s", route.Description)
+		}
+	}
+	if !found {
+		t.Error("Expected /api/items in Routes()")
+	}
+}
+
+type testModule struct {
+	registered bool
+	stopped    bool
+	checkErr   error
+}
+
+func (m *testModule) Name() string { return "test" }
+
+func (m *testModule) Register(s *Server) error {
+	m.registered = true
+	s.HandleFunc(http.MethodGet, "/module", func(w http.ResponseWriter, r *http.Request) {})
+	s.AddHealthCheck("dependency", func(ctx context.Context) error { return m.checkErr })
+	s.OnShutdown(func(ctx context.Context) error {
+		m.stopped = true
+		return nil
+	})
+	return nil
+}
+
+type failingModule struct{}
+
+func (failingModule) Name() string             { return "failing" }
+func (failingModule) Register(s *Server) error { return errors.New("boom") }
+
+func TestServer_RegisterModule(t *testing.T) {
+	s := newTestServer(t)
+	m := &testModule{}
+
+	if err := s.Register(m); err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+	if !m.registered {
+		t.Fatal("Expected module Register to be called")
+	}
+	if got := s.Modules(); len(got) != 1 || got[0] != "test" {
+		t.Errorf("Unexpected modules: %v", got)
+	}
+
+	if w := serve(s, http.MethodGet, "/module"); w.Code != http.StatusOK {
+		t.Errorf("Expected module route to respond 200, got %d", w.Code)
+	}
+
+	s.handler.SetReady(true)
+	if w := serve(s, http.MethodGet, "/ready"); w.Code != http.StatusOK {
+		t.Errorf("Expected ready 200 with passing check, got %d", w.Code)
+	}
+
+	m.checkErr = errors.New("down")
+	w := serve(s, http.MethodGet, "/ready")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected ready 503 with failing check, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"FAIL"`) || strings.Contains(w.Body.String(), "down") {
+		t.Errorf("Expected failing check without error details in body, got %s", w.Body.String())
+	}
+
+	if err := s.Register(failingModule{}); err == nil || !strings.Contains(err.Error(), "module failing") {
+		t.Errorf("Expected wrapped module error, got %v", err)
+	}
+}
+
+func TestServer_ShutdownHooks(t *testing.T) {
+	s := newTestServer(t)
+	m := &testModule{}
+	if err := s.Register(m); err != nil {
+		t.Fatal(err)
+	}
+
+	var order []int
+	s.OnShutdown(func(ctx context.Context) error { order = append(order, 1); return nil })
+	s.OnShutdown(func(ctx context.Context) error { order = append(order, 2); return errors.New("hook failed") })
+
+	done := make(chan error, 1)
+	go func() { done <- s.Shutdown() }()
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "hook failed") {
+			t.Errorf("Expected hook error to be returned, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown did not return")
+	}
+
+	if !m.stopped {
+		t.Error("Expected module shutdown hook to run")
+	}
+	if len(order) != 2 || order[0] != 2 || order[1] != 1 {
+		t.Errorf("Expected hooks in reverse order, got %v", order)
+	}
+}
+
+func TestServer_ShutdownHooksAfterForcedStop(t *testing.T) {
+	s := newTestServer(t)
+	s.shutdownTimeout = 50 * time.Millisecond
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	s.HandleFunc(http.MethodGet, "/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	})
+
+	var hookCtxErr error
+	hookRan := false
+	s.OnShutdown(func(ctx context.Context) error {
+		hookRan, hookCtxErr = true, ctx.Err()
+		return nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.httpServer.Serve(ln)
+	go http.Get("http://" + ln.Addr().String() + "/slow")
+	<-entered
+
+	err = s.Shutdown()
+	if err == nil || !strings.Contains(err.Error(), "forced to shutdown") {
+		t.Errorf("Expected forced shutdown error, got %v", err)
+	}
+	if !hookRan || hookCtxErr != nil {
+		t.Errorf("Expected hook to run with a live context after forced stop, ran=%v ctx err=%v", hookRan, hookCtxErr)
+	}
+}
+
+func TestServer_GroupUseAndRouteChain(t *testing.T) {
+	s := newTestServer(t)
+
+	admin := s.Group("/admin")
+	admin.HandleFunc(http.MethodGet, "/before", func(w http.ResponseWriter, r *http.Request) {})
+	admin.Use(headerMiddleware("auth", "auth"))
+	admin.HandleFunc(http.MethodGet, "/after", func(w http.ResponseWriter, r *http.Request) {})
+
+	if w := serve(s, http.MethodGet, "/admin/before"); w.Header().Get("X-Test") != "" {
+		t.Error("Expected Use to affect only routes registered after it")
+	}
+	if w := serve(s, http.MethodGet, "/admin/after"); w.Header().Get("X-Test") != "auth" {
+		t.Error("Expected group middleware on /admin/after")
+	}
+
+	chain, ok := s.RouteChain(http.MethodGet, "/admin/after")
+	if !ok {
+		t.Fatal("Expected chain for /admin/after")
+	}
+	if chain[0] != "request_id" || chain[len(chain)-1] != "auth" {
+		t.Errorf("Unexpected chain: %v", chain)
+	}
+
+	if _, ok := s.RouteChain(http.MethodGet, "/missing"); ok {
+		t.Error("Expected no chain for unknown route")
+	}
+}
+
+func TestServer_DebugRoutesOnlyInDevelopment(t *testing.T) {
+	s := newTestServer(t)
+	if w := serve(s, http.MethodGet, "/debug/routes"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 outside development, got %d", w.Code)
+	}
+
+	dev, err := New(&config.Config{
+		Server: config.ServerConfig{Port: "0"},
+		App:    config.AppConfig{Environment: "development"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(dev, http.MethodGet, "/debug/routes")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 in development, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"path":"/health"`) {
+		t.Errorf("Expected /health in routes, got %s", w.Body.String())
+	}
+}
+
+// TestServer_ResponsesMatchOpenAPI падает, если JSON ответ встроенного маршрута
+// расходится со схемой, объявленной для него в OpenAPI документе
+func TestServer_ResponsesMatchOpenAPI(t *testing.T) {
+	s, err := New(&config.Config{
+		Server:  config.ServerConfig{Port: "0"},
+		App:     config.AppConfig{Environment: "development", Version: "1.0.0-test"},
+		Metrics: config.MetricsConfig{Enabled: true, Path: "/prometheus"},
+		OpenAPI: config.OpenAPIConfig{Enabled: true, UIEnabled: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.handler.SetReady(true)
+
+	doc := s.OpenAPI()
+	checked := 0
+
+	for _, path := range doc.SortedPaths() {
+		op, ok := doc.Operation(http.MethodGet, path)
+		if !ok {
+			continue
+		}
+
+		// Потоковые ответы не завершаются сами и проверяются отдельно
+		if resp, ok := op.Responses["200"]; ok && resp.Content["text/event-stream"] != nil {
+			continue
+		}
+
+		t.Run(path, func(t *testing.T) {
+			w := serve(s, http.MethodGet, path)
+
+			resp, ok := op.Responses[strconv.Itoa(w.Code)]
+			if !ok {
+				t.Fatalf("status %d is not declared for GET %s", w.Code, path)
+			}
+
+			media, ok := resp.Content["application/json"]
+			if !ok {
+				return
+			}
+
+			var body interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+
+			for _, fe := range doc.Validate(media.Schema, body) {
+				t.Errorf("GET %s: %s %s", path, fe.Field, fe.Message)
+			}
+			checked++
+		})
+	}
+
+	if checked < 5 {
+		t.Errorf("expected at least 5 JSON routes to be validated, got %d", checked)
+	}
+}
+
+func TestServer_OpenAPIEndpoints(t *testing.T) {
+	s, err := New(&config.Config{
+		Server:  config.ServerConfig{Port: "0"},
+		App:     config.AppConfig{Environment: "test", Version: "1.0.0-test"},
+		OpenAPI: config.OpenAPIConfig{Enabled: true, UIEnabled: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(s, http.MethodGet, "/openapi.json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for /openapi.json, got %d", w.Code)
+	}
+
+	var doc struct {
+		OpenAPI string                 `json:"openapi"`
+		Paths   map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("Expected OpenAPI 3.1.0, got %s", doc.OpenAPI)
+	}
+	if _, ok := doc.Paths["/health"]; !ok {
+		t.Error("Expected /health in document paths")
+	}
+
+	w = serve(s, http.MethodGet, "/docs")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `spec-url="/openapi.json"`) {
+		t.Errorf("Expected docs page referencing the spec, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), openapi.DefaultUIScriptURL) || strings.Contains(w.Body.String(), "latest") {
+		t.Errorf("Expected pinned Redoc version, got %s", w.Body.String())
+	}
+}
+
+func TestServer_DocsScriptIntegrity(t *testing.T) {
+	s, err := New(&config.Config{
+		Server: config.ServerConfig{Port: "0"},
+		App:    config.AppConfig{Environment: "test"},
+		OpenAPI: config.OpenAPIConfig{
+			Enabled:           true,
+			UIEnabled:         true,
+			UIScriptURL:       "/static/redoc.standalone.js",
+			UIScriptIntegrity: "sha384-abc+/=",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(s, http.MethodGet, "/docs")
+	want := `<script src="/static/redoc.standalone.js" integrity="sha384-abc&#43;/=" crossorigin="anonymous"></script>`
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("Expected self-hosted script with integrity, got %s", w.Body.String())
+	}
+}
+
+func TestServer_RequestValidation(t *testing.T) {
+	s, err := New(&config.Config{
+		Server:  config.ServerConfig{Port: "0"},
+		App:     config.AppConfig{Environment: "test"},
+		OpenAPI: config.OpenAPIConfig{ValidateRequests: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.HandleFunc(http.MethodGet, "/items", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}, WithOperation(openapi.NewOperation("List items").Query("limit", 0, true, "").NoContent(http.StatusNoContent)))
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/items?limit=5", http.StatusNoContent},
+		{"/items", http.StatusBadRequest},
+		{"/items?limit=five", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		if w := serve(s, http.MethodGet, tt.path); w.Code != tt.status {
+			t.Errorf("GET %s: expected %d, got %d: %s", tt.path, tt.status, w.Code, w.Body.String())
+		}
+	}
+
+	chain, _ := s.RouteChain(http.MethodGet, "/items")
+	if last := chain[len(chain)-1]; last != "validate[request]" {
+		t.Errorf("Expected validator to be the innermost middleware, got %v", chain)
+	}
+}
+
+func TestServer_ShutdownClosesMetricsStreams(t *testing.T) {
+	s := newTestServer(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.httpServer.Serve(ln)
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/metrics/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected stream to open, got %d", resp.StatusCode)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Shutdown() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected clean shutdown with an open stream, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown blocked on an open metrics stream")
+	}
+}
+
+func TestServer_Dashboard(t *testing.T) {
+	s, err := New(&config.Config{
+		Server:    config.ServerConfig{Port: "0"},
+		App:       config.AppConfig{Environment: "test"},
+		Admin:     config.AdminConfig{Username: "admin", Password: "s3cret"},
+		Dashboard: config.DashboardConfig{Enabled: true, Path: "/dashboard"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		auth   bool
+		status int
+	}{
+		{"page requires auth", "/dashboard", false, http.StatusUnauthorized},
+		{"assets require auth", "/dashboard/assets/dashboard.js", false, http.StatusUnauthorized},
+		{"recent errors require auth", "/dashboard/errors", false, http.StatusUnauthorized},
+		{"recent errors", "/dashboard/errors", true, http.StatusOK},
+		{"page", "/dashboard", true, http.StatusOK},
+		{"script", "/dashboard/assets/dashboard.js", true, http.StatusOK},
+		{"missing asset", "/dashboard/assets/nope.js", true, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.auth {
+				req.SetBasicAuth("admin", "s3cret")
+			}
+			w := httptest.NewRecorder()
+			s.httpServer.Handler.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+
+	chain, ok := s.RouteChain(http.MethodGet, "/dashboard")
+	if !ok || chain[len(chain)-1] != "basic_auth" {
+		t.Errorf("Expected dashboard to be behind basic_auth, got %v", chain)
+	}
+
+	// Пути и request_id ошибок не попадают в публичные /metrics
+	w := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if strings.Contains(w.Body.String(), "recent_errors") {
+		t.Errorf("Expected no recent errors in public /metrics, got %s", w.Body.String())
+	}
+}
+
+func TestServer_ShutdownPushesMetrics(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		pushed []string
+	)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		pushed = append(pushed, r.Method+" "+r.URL.Path+"\n"+string(body))
+		mu.Unlock()
+	}))
+	defer gateway.Close()
+
+	s, err := New(&config.Config{
+		Server: config.ServerConfig{Port: "0"},
+		App:    config.AppConfig{Environment: "test"},
+		Metrics: config.MetricsConfig{
+			Enabled:             true,
+			Path:                "/prometheus",
+			HistoryResolution:   time.Second,
+			HistoryRetention:    time.Minute,
+			PushgatewayURL:      gateway.URL,
+			PushgatewayJob:      "web-server",
+			PushgatewayInstance: "replica-1",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.shutdownTimeout = 50 * time.Millisecond
+
+	w := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	// Незавершенный запрос приводит к принудительной остановке, но метрики отправляются
+	entered, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	s.HandleFunc(http.MethodGet, "/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.httpServer.Serve(ln)
+	go http.Get("http://" + ln.Addr().String() + "/slow")
+	<-entered
+
+	if err := s.Shutdown(); err == nil || !strings.Contains(err.Error(), "forced to shutdown") {
+		t.Fatalf("Expected forced shutdown error, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(pushed) != 1 {
+		t.Fatalf("Expected exactly one push on shutdown, got %d", len(pushed))
+	}
+	request := strings.SplitN(pushed[0], "\n", 2)[0]
+	if !strings.HasPrefix(request, "PUT /metrics/job/web-server/") ||
+		!strings.Contains(request, "/environment/test") || !strings.Contains(request, "/instance/replica-1") {
+		t.Errorf("Unexpected push request: %s", request)
+	}
+	// Тело в protobuf формате, поэтому проверяются только строки имени и метки
+	if !strings.Contains(pushed[0], "http_requests_total") || !strings.Contains(pushed[0], "/health") {
+		t.Error("Expected the last request to be included in the final push")
+	}
+}
+
+func TestServer_SLOMetrics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slo.yaml")
+	spec := "objectives:\n  - {name: health, route: GET /health, type: availability, target: 0.999}\n"
+	if err := os.WriteFile(path, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(&config.Config{
+		Server:  config.ServerConfig{Port: "0"},
+		App:     config.AppConfig{Environment: "test"},
+		Metrics: config.MetricsConfig{Enabled: true, Path: "/prometheus"},
+		SLO:     config.SLOConfig{File: path},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.metrics.Close()
+
+	serve(s, http.MethodGet, "/health")
+	serve(s, http.MethodGet, "/version")
+
+	body := serve(s, http.MethodGet, "/prometheus").Body.String()
+	for _, want := range []string{
+		`slo_events_total{slo="health"} 1`,
+		`slo_good_events_total{slo="health"} 1`,
+		`slo_objective_ratio{slo="health"} 0.999`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in metrics output", want)
+		}
+	}
+
+	chain, _ := s.RouteChain(http.MethodGet, "/health")
+	if !strings.Contains(strings.Join(chain, " "), "stats slo") {
+		t.Errorf("Expected slo middleware after stats, got %v", chain)
+	}
+}
+
+func TestServer_InvalidSLOFile(t *testing.T) {
+	_, err := New(&config.Config{
+		Server:  config.ServerConfig{Port: "0"},
+		App:     config.AppConfig{Environment: "test"},
+		Metrics: config.MetricsConfig{Enabled: true, Path: "/prometheus"},
+		SLO:     config.SLOConfig{File: filepath.Join(t.TempDir(), "missing.yaml")},
+	})
+	if err == nil {
+		t.Error("Expected error for missing SLO file")
+	}
+}
+
+func TestServer_MetricsScrapeAuth(t *testing.T) {
+	s, err := New(&config.Config{
+		Server: config.ServerConfig{Port: "0"},
+		App:    config.AppConfig{Environment: "test"},
+		Metrics: config.MetricsConfig{
+			Enabled:           true,
+			Path:              "/prometheus",
+			ScrapeBearerToken: "0123456789abcdef",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.metrics.Close()
+
+	if w := serve(s, http.MethodGet, "/prometheus"); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/prometheus", nil)
+	req.Header.Set("Authorization", "Bearer 0123456789abcdef")
+	w := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "http_requests_total") {
+		t.Errorf("Expected metrics with valid token, got %d", w.Code)
+	}
+
+	// Остальные маршруты авторизация метрик не затрагивает
+	if w := serve(s, http.MethodGet, "/health"); w.Code != http.StatusOK {
+		t.Errorf("Expected /health to stay open, got %d", w.Code)
+	}
+	chain, _ := s.RouteChain(http.MethodGet, "/prometheus")
+	if chain[len(chain)-1] != "scrape_auth" {
+		t.Errorf("Expected scrape_auth on metrics route, got %v", chain)
+	}
+}
+
+func TestServer_APIKeyRoutes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	writeKeys := func(keys string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte("keys:\n"+keys), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeKeys("  - {id: billing, hash: sha256:" + sha256Hex("key-1") + ", scopes: [orders:read, orders:write]}\n")
+
+	s, err := New(&config.Config{
+		Server:  config.ServerConfig{Port: "0"},
+		App:     config.AppConfig{Environment: "test"},
+		APIKeys: config.APIKeysConfig{File: path},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Scopes группы и маршрута складываются
+	api := s.Group("/api", WithAPIKey("orders:read"))
+	api.HandleFunc(http.MethodPost, "/orders", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}, WithAPIKey("orders:write"), WithOperation(openapi.NewOperation("").NoContent(http.StatusCreated)))
+
+	// Одна операция на маршрутах с ключом и без него
+	shared := openapi.NewOperation("List items").NoContent(http.StatusOK)
+	for _, prefix := range []string{"/api/a", "/api/b", "/public"} {
+		opts := []RouteOption{WithOperation(shared)}
+		if prefix == "/public" {
+			s.HandleFunc(http.MethodGet, prefix+"/items", func(w http.ResponseWriter, r *http.Request) {}, opts...)
+			continue
+		}
+		api.HandleFunc(http.MethodGet, strings.TrimPrefix(prefix, "/api")+"/items", func(w http.ResponseWriter, r *http.Request) {}, opts...)
+	}
+
+	call := func(key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/orders", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := call("key-1"); code != http.StatusCreated {
+		t.Errorf("Expected 201 with valid key, got %d", code)
+	}
+	if code := call("key-2"); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with unknown key, got %d", code)
+	}
+
+	// Ротация: после ReloadAPIKeys принимается только новый ключ
+	writeKeys("  - {id: billing, hash: sha256:" + sha256Hex("key-2") + ", scopes: [orders:read]}\n")
+	if err := s.ReloadAPIKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if code := call("key-1"); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with rotated key, got %d", code)
+	}
+	if code := call("key-2"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 without orders:write scope, got %d", code)
+	}
+
+	chain, _ := s.RouteChain(http.MethodPost, "/api/orders")
+	if chain[len(chain)-1] != "api_key" {
+		t.Errorf("Expected api_key on route, got %v", chain)
+	}
+	responses := s.OpenAPI().Paths["/api/orders"]["post"].Responses
+	if _, ok := responses["401"]; !ok {
+		t.Errorf("Expected 401 response in OpenAPI, got %v", responses)
+	}
+
+	if len(shared.Responses) != 1 {
+		t.Errorf("Expected shared operation to stay unchanged, got %+v", shared.Responses)
+	}
+	if _, ok := s.OpenAPI().Paths["/public/items"]["get"].Responses["401"]; ok {
+		t.Error("Expected no 401 response on a route without an API key")
+	}
+	if _, ok := s.OpenAPI().Paths["/api/b/items"]["get"].Responses["403"]; !ok {
+		t.Error("Expected 403 response on every route with an API key")
+	}
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestServer_StartListenErrors(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	_, busyPort, _ := net.SplitHostPort(busy.Addr().String())
+
+	tests := []struct {
+		name   string
+		server config.ServerConfig
+		err    string
+	}{
+		{"port in use", config.ServerConfig{Port: busyPort}, "could not listen on port"},
+		{"unix socket in missing directory", config.ServerConfig{Port: "0", SocketPath: filepath.Join(t.TempDir(), "missing", "server.sock")}, "unix socket"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(&config.Config{Server: tt.server, App: config.AppConfig{Environment: "test"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			done := make(chan error, 1)
+			go func() { done <- s.Start() }()
+
+			select {
+			case err := <-done:
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Expected error containing %q, got %v", tt.err, err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Start did not return on a listen error")
+			}
+
+			// Сервер, который не слушает сокеты, не должен объявлять готовность
+			if w := serve(s, http.MethodGet, "/ready"); w.Code != http.StatusServiceUnavailable {
+				t.Errorf("Expected /ready 503 after failed start, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestListenUnix_ExistingPath(t *testing.T) {
+	// Короткий путь: длина пути Unix сокета ограничена
+	dir, err := os.MkdirTemp("", "sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		prepare func(path string) func()
+		err     string
+	}{
+		{"missing", func(string) func() { return func() {} }, ""},
+		{"stale socket", func(path string) func() {
+			ln, err := net.Listen("unix", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ln.(*net.UnixListener).SetUnlinkOnClose(false)
+			ln.Close()
+			return func() {}
+		}, ""},
+		{"regular file", func(path string) func() {
+			if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			return func() {
+				if data, err := os.ReadFile(path); err != nil || string(data) != "data" {
+					t.Errorf("regular file must not be removed: %v", err)
+				}
+			}
+		}, "not a socket"},
+		{"live socket", func(path string) func() {
+			ln, err := net.Listen("unix", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return func() {
+				defer ln.Close()
+				conn, err := net.Dial("unix", path)
+				if err != nil {
+					t.Errorf("live socket must not be removed: %v", err)
+					return
+				}
+				conn.Close()
+			}
+		}, "in use"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, strconv.Itoa(i)+".sock")
+			check := tt.prepare(path)
+
+			ln, err := listenUnix(path)
+			if ln != nil {
+				ln.Close()
+			}
+			if tt.err == "" && err != nil {
+				t.Errorf("Expected listener, got %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("Expected error containing %q, got %v", tt.err, err)
+			}
+			check()
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package slo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// burnTier - пара окон multi-window multi-burn-rate алерта (Google SRE Workbook, гл. 5).
+// Алерт срабатывает, если за long израсходована доля budget бюджета окна SLO,
+// а короткое окно подтверждает, что расход продолжается.
+type burnTier struct {
+	long     time.Duration
+	short    time.Duration
+	budget   float64
+	severity string
+}
+
+var burnTiers = []burnTier{
+	{long: time.Hour, short: 5 * time.Minute, budget: 0.02, severity: "critical"},
+	{long: 6 * time.Hour, short: 30 * time.Minute, budget: 0.05, severity: "critical"},
+	{long: 3 * 24 * time.Hour, short: 6 * time.Hour, budget: 0.10, severity: "warning"},
+}
+
+// RuleFile - файл правил Prometheus
+type RuleFile struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+// RuleGroup - группа правил
+type RuleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule - записывающее правило или алерт
+type Rule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Rules строит записывающие правила и алерты для всех SLO. job - значение
+// метки job, под которой Prometheus собирает метрики сервера.
+func (s *Spec) Rules(job string) RuleFile {
+	var file RuleFile
+	for _, o := range s.Objectives {
+		file.Groups = append(file.Groups, o.rules(job))
+	}
+	return file
+}
+
+// RulesYAML возвращает правила в формате файла правил Prometheus
+func (s *Spec) RulesYAML(job string) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString("# Generated by `server slo rules`. DO NOT EDIT.\n")
+
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(s.Rules(job)); err != nil {
+		return nil, fmt.Errorf("failed to encode rules: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// rules строит группу правил одного SLO
+func (o Objective) rules(job string) RuleGroup {
+	window := time.Duration(o.Window)
+	selector := fmt.Sprintf(`{job=%q,slo=%q}`, job, o.Name)
+	errorBudget := "(1 - " + formatFloat(o.Target) + ")"
+
+	// Окна, для которых нужна доля ошибок: окна алертов и окно SLO
+	windows := map[time.Duration]bool{window: true}
+	var tiers []burnTier
+	for _, tier := range burnTiers {
+		if tier.long >= window {
+			continue
+		}
+		tiers = append(tiers, tier)
+		windows[tier.long] = true
+		windows[tier.short] = true
+	}
+	sorted := make([]time.Duration, 0, len(windows))
+	for w := range windows {
+		sorted = append(sorted, w)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	group := RuleGroup{Name: "slo-" + o.Name}
+	labels := map[string]string{"slo": o.Name}
+
+	for _, w := range sorted {
+		rng := formatDuration(w)
+		group.Rules = append(group.Rules, Rule{
+			Record: "slo:sli_error:ratio_rate" + rng,
+			Expr: fmt.Sprintf("1 - (sum(rate(slo_good_events_total%s[%s])) / sum(rate(slo_events_total%s[%s])))",
+				selector, rng, selector, rng),
+			Labels: labels,
+		})
+	}
+
+	group.Rules = append(group.Rules, Rule{
+		Record: "slo:error_budget_remaining:ratio",
+		Expr: fmt.Sprintf(`1 - slo:sli_error:ratio_rate%s{slo=%q} / %s`,
+			formatDuration(window), o.Name, errorBudget),
+		Labels: labels,
+	})
+
+	for _, tier := range tiers {
+		// Скорость расхода, при которой за long уходит доля budget бюджета окна SLO
+		burnRate := formatFloat(math.Round(tier.budget*float64(window)/float64(tier.long)*1000) / 1000)
+		long, short := formatDuration(tier.long), formatDuration(tier.short)
+
+		group.Rules = append(group.Rules, Rule{
+			Alert: "SLOErrorBudgetBurn",
+			Expr: fmt.Sprintf("slo:sli_error:ratio_rate%s{slo=%q} > (%s * %s)\nand\nslo:sli_error:ratio_rate%s{slo=%q} > (%s * %s)",
+				long, o.Name, burnRate, errorBudget, short, o.Name, burnRate, errorBudget),
+			Labels: map[string]string{
+				"slo":         o.Name,
+				"severity":    tier.severity,
+				"long_window": long,
+			},
+			Annotations: map[string]string{
+				"summary": fmt.Sprintf("SLO %s is burning its error budget %sx too fast", o.Name, burnRate),
+				"description": fmt.Sprintf("%s on %s: error ratio over the last %s is {{ $value | humanizePercentage }}, "+
+					"more than %sx the rate allowed by the %s %s SLO over %s (%s of the error budget in %s).",
+					o.describe(), o.routeName(), long, burnRate, formatPercent(o.Target), o.Type,
+					formatDuration(window), formatPercent(tier.budget), long),
+			},
+		})
+	}
+
+	return group
+}
+
+// describe - человекочитаемое описание плохих событий SLI
+func (o Objective) describe() string {
+	if o.Type == Latency {
+		return "Requests slower than " + formatDuration(time.Duration(o.Threshold))
+	}
+	return "5xx responses"
+}
+
+// routeName - маршрут SLO для текста алерта
+func (o Objective) routeName() string {
+	if o.Route == AllRoutes {
+		return "all routes"
+	}
+	return o.Route
+}
+
+// formatFloat печатает число без лишних нулей и ошибок округления (0.999, 14.4)
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatPercent печатает долю в процентах: 0.999 -> 99.9%
+func formatPercent(v float64) string {
+	return strconv.FormatFloat(v*100, 'g', 6, 64) + "%"
+}
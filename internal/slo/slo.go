@@ -0,0 +1,185 @@
+// Package slo описывает целевые уровни обслуживания (SLO) маршрутов: считает
+// хорошие и все события (SLI) для Prometheus и генерирует правила
+// multi-window multi-burn-rate для записи и алертов.
+package slo
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Типы SLO
+const (
+	// Availability - доля запросов без ответа 5xx
+	Availability = "availability"
+	// Latency - доля успешных запросов быстрее Threshold
+	Latency = "latency"
+)
+
+// AllRoutes в поле Route означает все зарегистрированные маршруты
+const AllRoutes = "*"
+
+// DefaultWindow - период, за который считается бюджет ошибок
+const DefaultWindow = 30 * 24 * time.Hour
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// Objective - одно SLO
+type Objective struct {
+	// Name - уникальное имя, значение метки slo
+	Name string `yaml:"name"`
+	// Route - маршрут в виде "GET /health" или AllRoutes
+	Route string `yaml:"route"`
+	// Type - Availability или Latency
+	Type string `yaml:"type"`
+	// Target - целевая доля хороших событий, например 0.999
+	Target float64 `yaml:"target"`
+	// Threshold - порог задержки для Latency
+	Threshold Duration `yaml:"threshold,omitempty"`
+	// Window - период бюджета ошибок; по умолчанию DefaultWindow
+	Window Duration `yaml:"window,omitempty"`
+}
+
+// Spec - содержимое файла SLO
+type Spec struct {
+	Objectives []Objective `yaml:"objectives"`
+}
+
+// Duration - time.Duration с разбором из YAML строк вида "300ms", "30d"
+type Duration time.Duration
+
+// UnmarshalYAML разбирает длительность; дополнительно поддерживаются дни ("30d")
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := parseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalYAML выводит длительность в том же формате
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return formatDuration(time.Duration(d)), nil
+}
+
+// Load читает и проверяет файл SLO
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SLO file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse разбирает и проверяет описание SLO в YAML
+func Parse(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid SLO file: %w", err)
+	}
+	for i := range spec.Objectives {
+		if spec.Objectives[i].Window == 0 {
+			spec.Objectives[i].Window = Duration(DefaultWindow)
+		}
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Validate проверяет описание SLO
+func (s *Spec) Validate() error {
+	seen := make(map[string]bool)
+	for _, o := range s.Objectives {
+		if !namePattern.MatchString(o.Name) {
+			return fmt.Errorf("invalid SLO name %q (lowercase letters, digits, '_' and '-')", o.Name)
+		}
+		if seen[o.Name] {
+			return fmt.Errorf("duplicate SLO name %q", o.Name)
+		}
+		seen[o.Name] = true
+
+		if o.Route != AllRoutes {
+			method, path, ok := strings.Cut(o.Route, " ")
+			if !ok || method == "" || !strings.HasPrefix(path, "/") {
+				return fmt.Errorf("SLO %s: route must be \"METHOD /path\" or %q, got %q", o.Name, AllRoutes, o.Route)
+			}
+		}
+
+		switch o.Type {
+		case Availability:
+			if o.Threshold != 0 {
+				return fmt.Errorf("SLO %s: threshold is only valid for latency objectives", o.Name)
+			}
+		case Latency:
+			if o.Threshold <= 0 {
+				return fmt.Errorf("SLO %s: latency objective requires a threshold", o.Name)
+			}
+		default:
+			return fmt.Errorf("SLO %s: unknown type %q (must be %s or %s)", o.Name, o.Type, Availability, Latency)
+		}
+
+		if o.Target <= 0 || o.Target >= 1 {
+			return fmt.Errorf("SLO %s: target must be between 0 and 1 exclusive, got %v", o.Name, o.Target)
+		}
+		if time.Duration(o.Window) < 24*time.Hour {
+			return fmt.Errorf("SLO %s: window must be at least 1d, got %s", o.Name, formatDuration(time.Duration(o.Window)))
+		}
+	}
+	return nil
+}
+
+// Matches сообщает, относится ли маршрут ("GET /health") к SLO
+func (o Objective) Matches(route string) bool {
+	return o.Route == AllRoutes || o.Route == route
+}
+
+// Good сообщает, является ли завершенный запрос хорошим событием SLO.
+// counted = false, если запрос не входит в SLI (5xx для latency SLO).
+func (o Objective) Good(status int, duration time.Duration) (good, counted bool) {
+	failed := status >= 500
+	if o.Type == Latency {
+		if failed {
+			return false, false
+		}
+		return duration <= time.Duration(o.Threshold), true
+	}
+	return !failed, true
+}
+
+// parseDuration разбирает длительность time.ParseDuration или целое число дней ("30d")
+func parseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		if _, err := fmt.Sscanf(days, "%d", &n); err == nil && fmt.Sprint(n) == days && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// formatDuration печатает длительность в формате Prometheus: 5m, 6h, 3d, 300ms
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	case d >= time.Second && d%time.Second == 0:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+	return fmt.Sprintf("%dms", d/time.Millisecond)
+}
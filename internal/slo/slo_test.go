@@ -0,0 +1,244 @@
+package slo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "availability and latency",
+			data: `
+objectives:
+  - name: api
+    route: "*"
+    type: availability
+    target: 0.999
+  - name: health-latency
+    route: GET /health
+    type: latency
+    threshold: 250ms
+    target: 0.99
+    window: 7d
+`,
+		},
+		{
+			name:    "unknown type",
+			data:    "objectives:\n  - {name: api, route: '*', type: uptime, target: 0.99}\n",
+			wantErr: "unknown type",
+		},
+		{
+			name:    "latency without threshold",
+			data:    "objectives:\n  - {name: api, route: '*', type: latency, target: 0.99}\n",
+			wantErr: "requires a threshold",
+		},
+		{
+			name:    "threshold on availability",
+			data:    "objectives:\n  - {name: api, route: '*', type: availability, threshold: 1s, target: 0.99}\n",
+			wantErr: "only valid for latency",
+		},
+		{
+			name:    "target out of range",
+			data:    "objectives:\n  - {name: api, route: '*', type: availability, target: 99.9}\n",
+			wantErr: "target must be between 0 and 1",
+		},
+		{
+			name:    "invalid route",
+			data:    "objectives:\n  - {name: api, route: /health, type: availability, target: 0.99}\n",
+			wantErr: "route must be",
+		},
+		{
+			name: "duplicate name",
+			data: "objectives:\n  - {name: api, route: '*', type: availability, target: 0.99}\n" +
+				"  - {name: api, route: GET /, type: availability, target: 0.99}\n",
+			wantErr: "duplicate SLO name",
+		},
+		{
+			name:    "invalid name",
+			data:    "objectives:\n  - {name: API SLO, route: '*', type: availability, target: 0.99}\n",
+			wantErr: "invalid SLO name",
+		},
+		{
+			name:    "window too short",
+			data:    "objectives:\n  - {name: api, route: '*', type: availability, target: 0.99, window: 1h}\n",
+			wantErr: "window must be at least 1d",
+		},
+		{
+			name:    "invalid duration",
+			data:    "objectives:\n  - {name: api, route: '*', type: availability, target: 0.99, window: 2w}\n",
+			wantErr: "invalid duration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := Parse([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := time.Duration(spec.Objectives[0].Window); got != DefaultWindow {
+				t.Errorf("expected default window %s, got %s", DefaultWindow, got)
+			}
+			if got := time.Duration(spec.Objectives[1].Window); got != 7*24*time.Hour {
+				t.Errorf("expected window 7d, got %s", got)
+			}
+			if got := time.Duration(spec.Objectives[1].Threshold); got != 250*time.Millisecond {
+				t.Errorf("expected threshold 250ms, got %s", got)
+			}
+		})
+	}
+}
+
+func TestObjective_Good(t *testing.T) {
+	availability := Objective{Type: Availability}
+	latency := Objective{Type: Latency, Threshold: Duration(100 * time.Millisecond)}
+
+	tests := []struct {
+		name        string
+		objective   Objective
+		status      int
+		duration    time.Duration
+		wantGood    bool
+		wantCounted bool
+	}{
+		{"availability ok", availability, 200, time.Second, true, true},
+		{"availability client error", availability, 404, 0, true, true},
+		{"availability server error", availability, 503, 0, false, true},
+		{"latency fast", latency, 200, 100 * time.Millisecond, true, true},
+		{"latency slow", latency, 200, 101 * time.Millisecond, false, true},
+		{"latency ignores server errors", latency, 500, time.Millisecond, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			good, counted := tt.objective.Good(tt.status, tt.duration)
+			if good != tt.wantGood || counted != tt.wantCounted {
+				t.Errorf("Good() = (%v, %v), want (%v, %v)", good, counted, tt.wantGood, tt.wantCounted)
+			}
+		})
+	}
+}
+
+func TestTracker(t *testing.T) {
+	spec := &Spec{Objectives: []Objective{
+		{Name: "api", Route: AllRoutes, Type: Availability, Target: 0.5},
+		{Name: "health", Route: "GET /health", Type: Latency, Threshold: Duration(100 * time.Millisecond), Target: 0.5},
+	}}
+	tracker := NewTracker(spec)
+
+	tracker.Observe("GET /health", 200, 10*time.Millisecond)
+	tracker.Observe("GET /health", 200, time.Second)
+	tracker.Observe("GET /health", 500, time.Millisecond)
+	tracker.Observe("GET /", 200, time.Millisecond)
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(tracker)
+
+	expected := `
+# HELP slo_events_total Total number of events counted by the SLI.
+# TYPE slo_events_total counter
+slo_events_total{slo="api"} 4
+slo_events_total{slo="health"} 2
+# HELP slo_good_events_total Number of good events counted by the SLI.
+# TYPE slo_good_events_total counter
+slo_good_events_total{slo="api"} 3
+slo_good_events_total{slo="health"} 1
+# HELP slo_error_budget_remaining_ratio Remaining error budget since process start (1 = untouched, negative = exhausted).
+# TYPE slo_error_budget_remaining_ratio gauge
+slo_error_budget_remaining_ratio{slo="api"} 0.5
+slo_error_budget_remaining_ratio{slo="health"} 0
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"slo_events_total", "slo_good_events_total", "slo_error_budget_remaining_ratio"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSpec_Rules(t *testing.T) {
+	spec, err := Parse([]byte(`
+objectives:
+  - name: api
+    route: "*"
+    type: availability
+    target: 0.999
+  - name: weekly
+    route: GET /
+    type: latency
+    threshold: 300ms
+    target: 0.99
+    window: 2d
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := spec.Rules("web-server")
+	if len(file.Groups) != 2 {
+		t.Fatalf("expected 2 rule groups, got %d", len(file.Groups))
+	}
+
+	alerts := func(g RuleGroup) map[string]Rule {
+		found := make(map[string]Rule)
+		for _, r := range g.Rules {
+			if r.Alert != "" {
+				found[r.Labels["long_window"]] = r
+			}
+		}
+		return found
+	}
+
+	api := alerts(file.Groups[0])
+	if len(api) != 3 {
+		t.Fatalf("expected 3 burn rate alerts for 30d window, got %d", len(api))
+	}
+	fast := api["1h"]
+	if fast.Labels["severity"] != "critical" {
+		t.Errorf("expected critical severity for 1h window, got %q", fast.Labels["severity"])
+	}
+	for _, want := range []string{
+		`slo:sli_error:ratio_rate1h{slo="api"} > (14.4 * (1 - 0.999))`,
+		`slo:sli_error:ratio_rate5m{slo="api"} > (14.4 * (1 - 0.999))`,
+	} {
+		if !strings.Contains(fast.Expr, want) {
+			t.Errorf("expected alert expr to contain %q, got %q", want, fast.Expr)
+		}
+	}
+	if got := api["3d"].Labels["severity"]; got != "warning" {
+		t.Errorf("expected warning severity for 3d window, got %q", got)
+	}
+
+	// Окно 3d не короче окна SLO 2d, такой алерт пропускается
+	if weekly := alerts(file.Groups[1]); len(weekly) != 2 {
+		t.Errorf("expected 2 burn rate alerts for 2d window, got %d", len(weekly))
+	}
+
+	data, err := spec.RulesYAML("web-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"record: slo:sli_error:ratio_rate30d",
+		`slo_good_events_total{job="web-server",slo="api"}[5m]`,
+		"record: slo:error_budget_remaining:ratio",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected rules to contain %q", want)
+		}
+	}
+}
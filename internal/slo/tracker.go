@@ -0,0 +1,99 @@
+package slo
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Tracker считает события SLI по каждому SLO и отдает их как метрики Prometheus:
+//
+//	slo_events_total{slo}                    - все события SLI
+//	slo_good_events_total{slo}               - хорошие события
+//	slo_objective_ratio{slo}                 - целевая доля хороших событий
+//	slo_error_budget_remaining_ratio{slo}    - остаток бюджета с момента запуска
+//
+// Остаток бюджета считается по счетчикам процесса и сбрасывается при перезапуске;
+// остаток за окно SLO дают записывающие правила из Rules.
+type Tracker struct {
+	objectives []trackedObjective
+
+	events    *prometheus.Desc
+	good      *prometheus.Desc
+	objective *prometheus.Desc
+	budget    *prometheus.Desc
+}
+
+type trackedObjective struct {
+	Objective
+	total atomic.Uint64
+	good  atomic.Uint64
+}
+
+// NewTracker создает Tracker для SLO из spec
+func NewTracker(spec *Spec) *Tracker {
+	t := &Tracker{
+		objectives: make([]trackedObjective, len(spec.Objectives)),
+		events: prometheus.NewDesc("slo_events_total",
+			"Total number of events counted by the SLI.", []string{"slo"}, nil),
+		good: prometheus.NewDesc("slo_good_events_total",
+			"Number of good events counted by the SLI.", []string{"slo"}, nil),
+		objective: prometheus.NewDesc("slo_objective_ratio",
+			"Target ratio of good events.", []string{"slo"}, nil),
+		budget: prometheus.NewDesc("slo_error_budget_remaining_ratio",
+			"Remaining error budget since process start (1 = untouched, negative = exhausted).", []string{"slo"}, nil),
+	}
+	for i, o := range spec.Objectives {
+		t.objectives[i].Objective = o
+	}
+	return t
+}
+
+// Observe учитывает завершенный запрос маршрута route во всех подходящих SLO
+func (t *Tracker) Observe(route string, status int, duration time.Duration) {
+	for i := range t.objectives {
+		o := &t.objectives[i]
+		if !o.Matches(route) {
+			continue
+		}
+		good, counted := o.Good(status, duration)
+		if !counted {
+			continue
+		}
+		o.total.Add(1)
+		if good {
+			o.good.Add(1)
+		}
+	}
+}
+
+// Describe реализует prometheus.Collector
+func (t *Tracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- t.events
+	ch <- t.good
+	ch <- t.objective
+	ch <- t.budget
+}
+
+// Collect реализует prometheus.Collector
+func (t *Tracker) Collect(ch chan<- prometheus.Metric) {
+	for i := range t.objectives {
+		o := &t.objectives[i]
+		total, good := o.total.Load(), o.good.Load()
+
+		ch <- prometheus.MustNewConstMetric(t.events, prometheus.CounterValue, float64(total), o.Name)
+		ch <- prometheus.MustNewConstMetric(t.good, prometheus.CounterValue, float64(good), o.Name)
+		ch <- prometheus.MustNewConstMetric(t.objective, prometheus.GaugeValue, o.Target, o.Name)
+		ch <- prometheus.MustNewConstMetric(t.budget, prometheus.GaugeValue, remainingBudget(total, good, o.Target), o.Name)
+	}
+}
+
+// remainingBudget - 1 минус доля израсходованного бюджета ошибок
+func remainingBudget(total, good uint64, target float64) float64 {
+	if total == 0 {
+		return 1
+	}
+	errorRate := float64(total-good) / float64(total)
+	return 1 - errorRate/(1-target)
+}
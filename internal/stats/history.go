@@ -0,0 +1,273 @@
+package stats
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Point - значения статистики за один интервал истории
+type Point struct {
+	Time       time.Time
+	Requests   uint64
+	Errors     uint64
+	Rate       float64
+	ErrorRate  float64
+	Latency    Percentiles
+	HeapAlloc  uint64
+	Goroutines int
+}
+
+// History хранит ограниченную историю статистики в кольцевом буфере:
+// одна точка на resolution, не больше retention/resolution точек
+type History struct {
+	stats      *Stats
+	resolution time.Duration
+	now        func() time.Time
+
+	mu     sync.RWMutex
+	points []Point
+	next   int
+	full   bool
+
+	lastTotal  uint64
+	lastErrors uint64
+	latency    *reservoir
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Значения по умолчанию для NewHistory
+const (
+	DefaultHistoryResolution = 10 * time.Second
+	DefaultHistoryRetention  = time.Hour
+)
+
+// NewHistory создает историю для s. Точки добавляются sampler'ом, запущенным через Start.
+// Нулевые resolution и retention заменяются значениями по умолчанию. Перцентили
+// задержки точки считаются по запросам ее интервала, поэтому у Stats одна история.
+func NewHistory(s *Stats, resolution, retention time.Duration) *History {
+	if resolution <= 0 {
+		resolution = DefaultHistoryResolution
+	}
+	if retention <= 0 {
+		retention = DefaultHistoryRetention
+	}
+
+	size := int(retention / resolution)
+	if size < 1 {
+		size = 1
+	}
+
+	latency := &reservoir{}
+	s.interval.Store(latency)
+
+	return &History{
+		stats:      s,
+		resolution: resolution,
+		now:        s.now,
+		points:     make([]Point, size),
+		latency:    latency,
+	}
+}
+
+// Resolution возвращает интервал между точками истории
+func (h *History) Resolution() time.Duration {
+	return h.resolution
+}
+
+// Retention возвращает длительность, которую покрывает буфер
+func (h *History) Retention() time.Duration {
+	return time.Duration(len(h.points)) * h.resolution
+}
+
+// Start запускает sampler, добавляющий точку каждые resolution
+func (h *History) Start() {
+	h.stop = make(chan struct{})
+	h.done = make(chan struct{})
+
+	h.mu.Lock()
+	h.lastTotal, h.lastErrors = h.stats.total.Load(), h.stats.errors.Load()
+	h.latency.take()
+	h.mu.Unlock()
+
+	go func() {
+		defer close(h.done)
+
+		ticker := time.NewTicker(h.resolution)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				h.Sample()
+			case <-h.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop останавливает sampler и дожидается его завершения
+func (h *History) Stop() {
+	if h.stop == nil {
+		return
+	}
+	close(h.stop)
+	<-h.done
+	h.stop = nil
+}
+
+// Sample добавляет точку с приростом счетчиков с предыдущего вызова
+func (h *History) Sample() {
+	total, errors := h.stats.total.Load(), h.stats.errors.Load()
+	latency := h.latency.take()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	p := Point{
+		Time:       h.now(),
+		Requests:   total - h.lastTotal,
+		Errors:     errors - h.lastErrors,
+		Latency:    latency,
+		HeapAlloc:  mem.HeapAlloc,
+		Goroutines: runtime.NumGoroutine(),
+	}
+	p.Rate = float64(p.Requests) / h.resolution.Seconds()
+	p.ErrorRate = ratio(p.Errors, p.Requests)
+	h.lastTotal, h.lastErrors = total, errors
+
+	h.points[h.next] = p
+	h.next = (h.next + 1) % len(h.points)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Points возвращает точки за последние window, объединенные по resolution
+// (округляется вверх до кратного интервалу истории). Нулевые значения означают
+// "вся история" и "без объединения". Точки упорядочены от старых к новым.
+func (h *History) Points(window, resolution time.Duration) []Point {
+	if resolution < h.resolution {
+		resolution = h.resolution
+	}
+	per := int((resolution + h.resolution - 1) / h.resolution)
+
+	h.mu.RLock()
+	var points []Point
+	if h.full {
+		points = append(points, h.points[h.next:]...)
+	}
+	points = append(points, h.points[:h.next]...)
+	h.mu.RUnlock()
+
+	if window > 0 && len(points) > 0 {
+		from := h.now().Add(-window)
+		i := 0
+		for i < len(points) && !points[i].Time.After(from) {
+			i++
+		}
+		points = points[i:]
+	}
+
+	if per == 1 {
+		return points
+	}
+
+	// Группы выравниваются по концу, чтобы последняя точка всегда была полной
+	merged := make([]Point, 0, len(points)/per+1)
+	for end := len(points); end > 0; end -= per {
+		start := end - per
+		if start < 0 {
+			start = 0
+		}
+		merged = append(merged, merge(points[start:end], time.Duration(per)*h.resolution))
+	}
+	for i, j := 0, len(merged)-1; i < j; i, j = i+1, j-1 {
+		merged[i], merged[j] = merged[j], merged[i]
+	}
+	return merged
+}
+
+// Since возвращает точки, добавленные после t, от старых к новым
+func (h *History) Since(t time.Time) []Point {
+	var points []Point
+	for _, p := range h.Points(0, 0) {
+		if p.Time.After(t) {
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+// merge объединяет последовательные точки в одну. Перцентили усредняются
+// с весом по количеству запросов, память и горутины берутся из последней точки.
+func merge(points []Point, resolution time.Duration) Point {
+	last := points[len(points)-1]
+	p := Point{Time: last.Time, HeapAlloc: last.HeapAlloc, Goroutines: last.Goroutines}
+
+	var p50, p90, p95, p99 float64
+	for _, pt := range points {
+		p.Requests += pt.Requests
+		p.Errors += pt.Errors
+		weight := float64(pt.Requests)
+		p50 += weight * float64(pt.Latency.P50)
+		p90 += weight * float64(pt.Latency.P90)
+		p95 += weight * float64(pt.Latency.P95)
+		p99 += weight * float64(pt.Latency.P99)
+	}
+
+	p.Rate = float64(p.Requests) / resolution.Seconds()
+	p.ErrorRate = ratio(p.Errors, p.Requests)
+	if p.Requests > 0 {
+		n := float64(p.Requests)
+		p.Latency = Percentiles{
+			P50: time.Duration(p50 / n),
+			P90: time.Duration(p90 / n),
+			P95: time.Duration(p95 / n),
+			P99: time.Duration(p99 / n),
+		}
+	}
+	return p
+}
+
+// reservoir - выборка задержек одного интервала истории (reservoir sampling,
+// как в интервалах скользящего окна)
+type reservoir struct {
+	mu      sync.Mutex
+	count   uint64
+	samples []time.Duration
+}
+
+// observe добавляет задержку в выборку
+func (r *reservoir) observe(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.count++
+	if len(r.samples) < maxSamples {
+		r.samples = append(r.samples, d)
+	} else if i := rand.Uint64N(r.count); i < maxSamples {
+		r.samples[i] = d
+	}
+}
+
+// take возвращает перцентили накопленной выборки и начинает новый интервал
+func (r *reservoir) take() Percentiles {
+	r.mu.Lock()
+	samples := make([]weighted, len(r.samples))
+	for i, v := range r.samples {
+		samples[i] = weighted{value: v, weight: 1}
+	}
+	r.count = 0
+	r.samples = r.samples[:0]
+	r.mu.Unlock()
+
+	return percentiles(samples)
+}
@@ -0,0 +1,333 @@
+// Package stats ведет учет обработанных запросов для JSON метрик сервера:
+// счетчики по маршрутам и статусам, число запросов в обработке, доля ошибок
+// и перцентили задержки в скользящих окнах.
+package stats
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Unmatched - имя маршрута для запросов, не дошедших до зарегистрированного обработчика
+// (404, 405, OPTIONS)
+const Unmatched = "unmatched"
+
+// DefaultWindows - окна, для которых считаются rate и перцентили задержки
+var DefaultWindows = []time.Duration{time.Minute, 5 * time.Minute}
+
+// Stats накапливает статистику запросов. Методы безопасны для конкурентного вызова.
+type Stats struct {
+	start time.Time
+	now   func() time.Time
+
+	total    atomic.Uint64
+	errors   atomic.Uint64
+	inFlight atomic.Int64
+
+	mu       sync.RWMutex
+	routes   map[string]*routeStats
+	statuses map[int]*atomic.Uint64
+
+	window  *window
+	windows []time.Duration
+	// interval - выборка задержек текущего интервала истории, если она создана
+	interval atomic.Pointer[reservoir]
+
+	errorsMu     sync.Mutex
+	recentErrors []ErrorEvent
+	nextError    int
+}
+
+// recentErrorsSize - сколько последних ошибок хранится для диагностики
+const recentErrorsSize = 20
+
+// maxErrorPathLength ограничивает длину пути, сохраняемого в ErrorEvent
+const maxErrorPathLength = 256
+
+// ErrorEvent описывает запрос, завершившийся ответом 5xx
+type ErrorEvent struct {
+	Time      time.Time
+	Method    string
+	Path      string
+	Route     string
+	Status    int
+	RequestID string
+	Duration  time.Duration
+}
+
+// routeStats - счетчики одного маршрута
+type routeStats struct {
+	requests atomic.Uint64
+	errors   atomic.Uint64
+	totalNs  atomic.Int64
+	maxNs    atomic.Int64
+}
+
+// New создает Stats со скользящими окнами DefaultWindows
+func New() *Stats {
+	return newStats(time.Now, DefaultWindows)
+}
+
+// newStats позволяет тестам подменить часы
+func newStats(now func() time.Time, windows []time.Duration) *Stats {
+	longest := time.Duration(0)
+	for _, w := range windows {
+		if w > longest {
+			longest = w
+		}
+	}
+
+	return &Stats{
+		start:    now(),
+		now:      now,
+		routes:   make(map[string]*routeStats),
+		statuses: make(map[int]*atomic.Uint64),
+		window:   newWindow(longest, now),
+		windows:  windows,
+	}
+}
+
+// StartTime возвращает время создания Stats (запуска сервера)
+func (s *Stats) StartTime() time.Time {
+	return s.start
+}
+
+// Begin отмечает начало обработки запроса
+func (s *Stats) Begin() {
+	s.inFlight.Add(1)
+}
+
+// End отмечает завершение запроса, начатого Begin. Ошибками считаются ответы 5xx.
+func (s *Stats) End(route string, status int, duration time.Duration) {
+	s.inFlight.Add(-1)
+
+	isError := status >= 500
+	s.total.Add(1)
+	if isError {
+		s.errors.Add(1)
+	}
+
+	rs := s.route(route)
+	rs.requests.Add(1)
+	if isError {
+		rs.errors.Add(1)
+	}
+	rs.totalNs.Add(int64(duration))
+	for {
+		max := rs.maxNs.Load()
+		if int64(duration) <= max || rs.maxNs.CompareAndSwap(max, int64(duration)) {
+			break
+		}
+	}
+
+	s.status(status).Add(1)
+	s.window.observe(duration, isError)
+	if r := s.interval.Load(); r != nil {
+		r.observe(duration)
+	}
+}
+
+// RecordError запоминает запрос с ответом 5xx в списке последних ошибок
+func (s *Stats) RecordError(e ErrorEvent) {
+	if len(e.Path) > maxErrorPathLength {
+		e.Path = e.Path[:maxErrorPathLength]
+	}
+
+	s.errorsMu.Lock()
+	defer s.errorsMu.Unlock()
+
+	if len(s.recentErrors) < recentErrorsSize {
+		s.recentErrors = append(s.recentErrors, e)
+	} else {
+		s.recentErrors[s.nextError] = e
+	}
+	s.nextError = (s.nextError + 1) % recentErrorsSize
+}
+
+// RecentErrors возвращает последние ошибки, начиная с самой новой
+func (s *Stats) RecentErrors() []ErrorEvent {
+	s.errorsMu.Lock()
+	defer s.errorsMu.Unlock()
+
+	result := make([]ErrorEvent, 0, len(s.recentErrors))
+	for i := 1; i <= len(s.recentErrors); i++ {
+		idx := (s.nextError - i + recentErrorsSize) % recentErrorsSize
+		result = append(result, s.recentErrors[idx])
+	}
+	return result
+}
+
+// Total возвращает количество завершенных запросов
+func (s *Stats) Total() uint64 {
+	return s.total.Load()
+}
+
+// route возвращает счетчики маршрута, создавая их при первом обращении
+func (s *Stats) route(name string) *routeStats {
+	s.mu.RLock()
+	rs, ok := s.routes[name]
+	s.mu.RUnlock()
+	if ok {
+		return rs
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rs, ok = s.routes[name]; !ok {
+		rs = &routeStats{}
+		s.routes[name] = rs
+	}
+	return rs
+}
+
+// status возвращает счетчик статуса, создавая его при первом обращении
+func (s *Stats) status(code int) *atomic.Uint64 {
+	s.mu.RLock()
+	c, ok := s.statuses[code]
+	s.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok = s.statuses[code]; !ok {
+		c = &atomic.Uint64{}
+		s.statuses[code] = c
+	}
+	return c
+}
+
+// Snapshot - согласованный на момент вызова срез статистики
+type Snapshot struct {
+	StartTime time.Time
+	Uptime    time.Duration
+	Total     uint64
+	Errors    uint64
+	ErrorRate float64
+	InFlight  int64
+	Statuses  map[string]uint64
+	Routes    []RouteSnapshot
+	Windows   []WindowSnapshot
+}
+
+// RouteSnapshot - статистика одного маршрута
+type RouteSnapshot struct {
+	Route      string
+	Requests   uint64
+	Errors     uint64
+	ErrorRate  float64
+	AvgLatency time.Duration
+	MaxLatency time.Duration
+}
+
+// WindowSnapshot - статистика за последнее окно времени
+type WindowSnapshot struct {
+	Window    time.Duration
+	Requests  uint64
+	Errors    uint64
+	Rate      float64
+	ErrorRate float64
+	Latency   Percentiles
+}
+
+// Percentiles - перцентили задержки
+type Percentiles struct {
+	P50 time.Duration
+	P90 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+}
+
+// Snapshot возвращает текущую статистику. Маршруты отсортированы по имени.
+func (s *Stats) Snapshot() Snapshot {
+	now := s.now()
+	total := s.total.Load()
+	errors := s.errors.Load()
+
+	snap := Snapshot{
+		StartTime: s.start,
+		Uptime:    now.Sub(s.start),
+		Total:     total,
+		Errors:    errors,
+		ErrorRate: ratio(errors, total),
+		InFlight:  s.inFlight.Load(),
+		Statuses:  make(map[string]uint64),
+	}
+
+	s.mu.RLock()
+	for code, c := range s.statuses {
+		snap.Statuses[strconv.Itoa(code)] = c.Load()
+	}
+	for name, rs := range s.routes {
+		requests := rs.requests.Load()
+		routeErrors := rs.errors.Load()
+		route := RouteSnapshot{
+			Route:      name,
+			Requests:   requests,
+			Errors:     routeErrors,
+			ErrorRate:  ratio(routeErrors, requests),
+			MaxLatency: time.Duration(rs.maxNs.Load()),
+		}
+		if requests > 0 {
+			route.AvgLatency = time.Duration(rs.totalNs.Load() / int64(requests))
+		}
+		snap.Routes = append(snap.Routes, route)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(snap.Routes, func(i, j int) bool {
+		return snap.Routes[i].Route < snap.Routes[j].Route
+	})
+
+	for _, w := range s.windows {
+		snap.Windows = append(snap.Windows, s.Window(w))
+	}
+
+	return snap
+}
+
+// Window возвращает статистику за последние d (не больше самого длинного окна)
+func (s *Stats) Window(d time.Duration) WindowSnapshot {
+	return s.window.snapshot(d)
+}
+
+// ratio возвращает part/total или 0, если total = 0
+func ratio(part, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
+
+type routeKey struct{}
+
+// routeHolder передается через контекст от stats middleware к обработчику маршрута
+type routeHolder struct {
+	route string
+}
+
+// WithRoute подготавливает контекст запроса для SetRoute
+func WithRoute(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routeKey{}, &routeHolder{route: Unmatched})
+}
+
+// SetRoute запоминает шаблон маршрута, которым обработан запрос ("GET /health").
+// Используется вместо URL.Path, чтобы число маршрутов в статистике было ограничено.
+func SetRoute(ctx context.Context, route string) {
+	if h, ok := ctx.Value(routeKey{}).(*routeHolder); ok {
+		h.route = route
+	}
+}
+
+// RouteFromContext возвращает маршрут, заданный SetRoute, или Unmatched
+func RouteFromContext(ctx context.Context) string {
+	if h, ok := ctx.Value(routeKey{}).(*routeHolder); ok {
+		return h.route
+	}
+	return Unmatched
+}
@@ -0,0 +1,238 @@
+package stats
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock - управляемые часы для тестов окон
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestStats_Snapshot(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := newStats(clock.Now, []time.Duration{time.Minute})
+
+	s.Begin()
+	s.Begin()
+	s.End("GET /a", 200, 10*time.Millisecond)
+	s.End("GET /b", 503, 30*time.Millisecond)
+	s.Begin()
+
+	clock.Advance(time.Second)
+	snap := s.Snapshot()
+
+	if snap.Total != 2 || snap.Errors != 1 || snap.ErrorRate != 0.5 {
+		t.Errorf("unexpected totals: %+v", snap)
+	}
+	if snap.InFlight != 1 {
+		t.Errorf("expected 1 request in flight, got %d", snap.InFlight)
+	}
+	if snap.Uptime != time.Second {
+		t.Errorf("expected uptime 1s, got %s", snap.Uptime)
+	}
+	if snap.Statuses["200"] != 1 || snap.Statuses["503"] != 1 {
+		t.Errorf("unexpected statuses: %v", snap.Statuses)
+	}
+	if len(snap.Routes) != 2 || snap.Routes[1].Route != "GET /b" || snap.Routes[1].ErrorRate != 1 {
+		t.Errorf("unexpected routes: %+v", snap.Routes)
+	}
+	if len(snap.Windows) != 1 || snap.Windows[0].Requests != 2 || snap.Windows[0].Rate != 2 {
+		t.Errorf("unexpected windows: %+v", snap.Windows)
+	}
+}
+
+func TestWindow_Percentiles(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := newStats(clock.Now, []time.Duration{time.Minute})
+
+	for i := 1; i <= 100; i++ {
+		s.End("GET /", 200, time.Duration(i)*time.Millisecond)
+	}
+
+	tests := []struct {
+		name string
+		got  time.Duration
+		want time.Duration
+	}{
+		{"p50", s.Window(time.Minute).Latency.P50, 50 * time.Millisecond},
+		{"p90", s.Window(time.Minute).Latency.P90, 90 * time.Millisecond},
+		{"p99", s.Window(time.Minute).Latency.P99, 99 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, tt.got)
+		}
+	}
+}
+
+func TestWindow_Expiry(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := newStats(clock.Now, []time.Duration{time.Minute})
+
+	s.End("GET /", 500, time.Millisecond)
+	clock.Advance(30 * time.Second)
+	s.End("GET /", 200, time.Millisecond)
+
+	if w := s.Window(time.Minute); w.Requests != 2 || w.Errors != 1 {
+		t.Errorf("expected both requests in the last minute, got %+v", w)
+	}
+
+	clock.Advance(45 * time.Second)
+	if w := s.Window(time.Minute); w.Requests != 1 || w.Errors != 0 {
+		t.Errorf("expected only the second request in the last minute, got %+v", w)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if w := s.Window(time.Minute); w.Requests != 0 || w.Latency.P99 != 0 {
+		t.Errorf("expected empty window, got %+v", w)
+	}
+
+	// Total не зависит от окна
+	if s.Total() != 2 {
+		t.Errorf("expected total 2, got %d", s.Total())
+	}
+}
+
+func TestRouteContext(t *testing.T) {
+	if got := RouteFromContext(context.Background()); got != Unmatched {
+		t.Errorf("expected %s without WithRoute, got %s", Unmatched, got)
+	}
+
+	ctx := WithRoute(context.Background())
+	SetRoute(ctx, "GET /health")
+	if got := RouteFromContext(ctx); got != "GET /health" {
+		t.Errorf("expected GET /health, got %s", got)
+	}
+}
+
+func TestHistory_SampleAndPoints(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := newStats(clock.Now, DefaultWindows)
+	h := NewHistory(s, 10*time.Second, time.Minute)
+
+	// 8 интервалов при буфере на 6: две самые старые точки вытесняются
+	for i := 1; i <= 8; i++ {
+		for j := 0; j < i; j++ {
+			status := 200
+			if j == 0 {
+				status = 500
+			}
+			s.End("GET /", status, time.Duration(i)*time.Millisecond)
+		}
+		clock.Advance(10 * time.Second)
+		h.Sample()
+	}
+
+	points := h.Points(0, 0)
+	if len(points) != 6 {
+		t.Fatalf("expected 6 points in a full buffer, got %d", len(points))
+	}
+	if points[0].Requests != 3 || points[5].Requests != 8 {
+		t.Errorf("expected oldest point with 3 and newest with 8 requests, got %d and %d",
+			points[0].Requests, points[5].Requests)
+	}
+	if points[5].Errors != 1 || points[5].Rate != 0.8 {
+		t.Errorf("unexpected newest point: %+v", points[5])
+	}
+	if points[5].HeapAlloc == 0 || points[5].Goroutines == 0 {
+		t.Errorf("expected memory and goroutines to be sampled, got %+v", points[5])
+	}
+
+	tests := []struct {
+		name       string
+		window     time.Duration
+		resolution time.Duration
+		requests   []uint64
+	}{
+		{"last 30s", 30 * time.Second, 0, []uint64{6, 7, 8}},
+		{"merged by 20s", 0, 20 * time.Second, []uint64{3 + 4, 5 + 6, 7 + 8}},
+		{"resolution rounded up", 0, 25 * time.Second, []uint64{3 + 4 + 5, 6 + 7 + 8}},
+		{"partial group first", 50 * time.Second, 30 * time.Second, []uint64{4 + 5, 6 + 7 + 8}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := h.Points(tt.window, tt.resolution)
+			if len(points) != len(tt.requests) {
+				t.Fatalf("expected %d points, got %d", len(tt.requests), len(points))
+			}
+			for i, want := range tt.requests {
+				if points[i].Requests != want {
+					t.Errorf("point %d: expected %d requests, got %d", i, want, points[i].Requests)
+				}
+			}
+		})
+	}
+}
+
+func TestHistory_StartStop(t *testing.T) {
+	h := NewHistory(New(), 0, 0)
+	if h.Resolution() != DefaultHistoryResolution || h.Retention() != DefaultHistoryRetention {
+		t.Errorf("expected defaults, got %s/%s", h.Resolution(), h.Retention())
+	}
+
+	h.Start()
+	h.Stop()
+	// Повторный Stop безопасен
+	h.Stop()
+}
+
+func TestStats_RecentErrors(t *testing.T) {
+	s := New()
+	for i := 0; i < recentErrorsSize+5; i++ {
+		s.RecordError(ErrorEvent{Status: 500 + i%4, RequestID: string(rune('a' + i%26))})
+	}
+	s.RecordError(ErrorEvent{Path: "/" + strings.Repeat("x", 1000), Status: 503})
+
+	recent := s.RecentErrors()
+	if len(recent) != recentErrorsSize {
+		t.Fatalf("expected %d recent errors, got %d", recentErrorsSize, len(recent))
+	}
+	if len(recent[0].Path) != maxErrorPathLength {
+		t.Errorf("expected newest error first with truncated path, got %d chars", len(recent[0].Path))
+	}
+	if recent[1].RequestID != string(rune('a'+recentErrorsSize+4)) {
+		t.Errorf("expected errors in newest-first order, got %q", recent[1].RequestID)
+	}
+}
+
+func TestHistory_LatencyPerInterval(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := newStats(clock.Now, DefaultWindows)
+	// Интервал длиннее скользящих окон и не кратен их интервалам
+	h := NewHistory(s, 7*time.Minute+3*time.Second, time.Hour)
+
+	for _, d := range []time.Duration{5 * time.Millisecond, 50 * time.Millisecond} {
+		for i := 0; i < 10; i++ {
+			s.End("GET /", 200, d)
+		}
+		clock.Advance(h.Resolution())
+		h.Sample()
+	}
+
+	points := h.Points(0, 0)
+	if len(points) != 2 {
+		t.Fatalf("expected 2 points, got %d", len(points))
+	}
+	if points[0].Latency.P99 != 5*time.Millisecond || points[1].Latency.P50 != 50*time.Millisecond {
+		t.Errorf("expected latency of each interval only, got %+v and %+v", points[0].Latency, points[1].Latency)
+	}
+}
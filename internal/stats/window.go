@@ -0,0 +1,146 @@
+package stats
+
+import (
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// slotWidth - ширина одного интервала скользящего окна
+	slotWidth = 10 * time.Second
+	// maxSamples - сколько задержек хранится в интервале (reservoir sampling)
+	maxSamples = 512
+)
+
+// slot - интервал окна: счетчики и выборка задержек
+type slot struct {
+	start   int64
+	count   uint64
+	errors  uint64
+	samples []time.Duration
+}
+
+// window - кольцевой буфер интервалов по slotWidth, покрывающий заданную длительность
+type window struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	start time.Time
+	slots []slot
+}
+
+// newWindow создает окно длиной не меньше length
+func newWindow(length time.Duration, now func() time.Time) *window {
+	n := int((length + slotWidth - 1) / slotWidth)
+	if n < 1 {
+		n = 1
+	}
+	return &window{now: now, start: now(), slots: make([]slot, n)}
+}
+
+// slotStart возвращает начало интервала, в который попадает t
+func slotStart(t time.Time) int64 {
+	return t.UnixNano() / int64(slotWidth) * int64(slotWidth)
+}
+
+// observe записывает задержку в текущий интервал
+func (w *window) observe(d time.Duration, isError bool) {
+	start := slotStart(w.now())
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	s := &w.slots[(start/int64(slotWidth))%int64(len(w.slots))]
+	if s.start != start {
+		*s = slot{start: start, samples: s.samples[:0]}
+	}
+
+	s.count++
+	if isError {
+		s.errors++
+	}
+	if len(s.samples) < maxSamples {
+		s.samples = append(s.samples, d)
+	} else if i := rand.Uint64N(s.count); i < maxSamples {
+		s.samples[i] = d
+	}
+}
+
+// weighted - задержка из выборки с весом (сколько запросов она представляет)
+type weighted struct {
+	value  time.Duration
+	weight float64
+}
+
+// snapshot собирает статистику интервалов, попадающих в последние d
+func (w *window) snapshot(d time.Duration) WindowSnapshot {
+	now := w.now()
+	maxLen := time.Duration(len(w.slots)) * slotWidth
+	if d <= 0 || d > maxLen {
+		d = maxLen
+	}
+	from := slotStart(now.Add(-d)) + int64(slotWidth)
+
+	result := WindowSnapshot{Window: d}
+	var samples []weighted
+
+	w.mu.Lock()
+	for i := range w.slots {
+		s := &w.slots[i]
+		if s.count == 0 || s.start < from || s.start > now.UnixNano() {
+			continue
+		}
+		result.Requests += s.count
+		result.Errors += s.errors
+
+		weight := float64(s.count) / float64(len(s.samples))
+		for _, v := range s.samples {
+			samples = append(samples, weighted{value: v, weight: weight})
+		}
+	}
+	w.mu.Unlock()
+
+	// Пока сервер работает меньше окна, rate считается по фактическому времени
+	covered := d
+	if uptime := now.Sub(w.start); uptime < covered {
+		covered = uptime
+	}
+	if covered > 0 {
+		result.Rate = float64(result.Requests) / covered.Seconds()
+	}
+	result.ErrorRate = ratio(result.Errors, result.Requests)
+	result.Latency = percentiles(samples)
+
+	return result
+}
+
+// percentiles вычисляет перцентили по взвешенной выборке
+func percentiles(samples []weighted) Percentiles {
+	if len(samples) == 0 {
+		return Percentiles{}
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].value < samples[j].value
+	})
+
+	var total float64
+	for _, s := range samples {
+		total += s.weight
+	}
+
+	at := func(q float64) time.Duration {
+		target := q * total
+		var cumulative float64
+		for _, s := range samples {
+			cumulative += s.weight
+			if cumulative >= target {
+				return s.value
+			}
+		}
+		return samples[len(samples)-1].value
+	}
+
+	return Percentiles{P50: at(0.50), P90: at(0.90), P95: at(0.95), P99: at(0.99)}
+}
@@ -0,0 +1,86 @@
+// Package tracing разбирает W3C Trace Context (заголовок traceparent), чтобы
+// метрики и логи можно было связать с трейсом, начатым клиентом или прокси.
+// Сервер не создает собственных span, он только передает идентификаторы дальше.
+package tracing
+
+import (
+	"context"
+	"strings"
+)
+
+// Header - заголовок W3C Trace Context
+const Header = "traceparent"
+
+// SpanContext - идентификаторы трейса из traceparent
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+	Sampled bool
+}
+
+// Active сообщает, что трейс записывается и на него можно сослаться из метрик
+func (sc SpanContext) Active() bool {
+	return sc.TraceID != "" && sc.Sampled
+}
+
+type contextKey struct{}
+
+// Parse разбирает значение traceparent: "00-<trace-id>-<parent-id>-<flags>".
+// Версии новее 00 принимаются, если начинаются с полей версии 00.
+func Parse(header string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, false
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+	if !isHex(traceID, 32) || isZero(traceID) || !isHex(spanID, 16) || isZero(spanID) || !isHex(flags, 2) {
+		return SpanContext{}, false
+	}
+
+	return SpanContext{
+		TraceID: traceID,
+		SpanID:  spanID,
+		Sampled: fromHex(flags[1])&1 == 1,
+	}, true
+}
+
+// WithContext возвращает контекст с идентификаторами трейса
+func WithContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, sc)
+}
+
+// FromContext возвращает идентификаторы трейса из контекста или пустой SpanContext
+func FromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(contextKey{}).(SpanContext)
+	return sc
+}
+
+// isHex проверяет, что s - строка из n строчных шестнадцатеричных символов
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// isZero проверяет, что идентификатор состоит из нулей (запрещено спецификацией)
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+func fromHex(c byte) byte {
+	if c >= 'a' {
+		return c - 'a' + 10
+	}
+	return c - '0'
+}
@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   SpanContext
+		ok     bool
+	}{
+		{
+			name:   "sampled",
+			header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want:   SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Sampled: true},
+			ok:     true,
+		},
+		{
+			name:   "not sampled",
+			header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			want:   SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"},
+			ok:     true,
+		},
+		{
+			name:   "future version with extra fields",
+			header: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-09-extra",
+			want:   SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Sampled: true},
+			ok:     true,
+		},
+		{"empty", "", SpanContext{}, false},
+		{"version 00 with extra fields", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-x", SpanContext{}, false},
+		{"invalid version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", SpanContext{}, false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", SpanContext{}, false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", SpanContext{}, false},
+		{"zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", SpanContext{}, false},
+		{"short trace id", "00-4bf92f3577b34da6-00f067aa0ba902b7-01", SpanContext{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Parse(tt.header)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("Parse(%q) = %+v, %v; want %+v, %v", tt.header, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	if FromContext(context.Background()).Active() {
+		t.Error("expected empty context to have no active trace")
+	}
+
+	sc := SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Sampled: true}
+	if got := FromContext(WithContext(context.Background(), sc)); got != sc || !got.Active() {
+		t.Errorf("expected %+v, got %+v", sc, got)
+	}
+}
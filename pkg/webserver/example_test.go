@@ -0,0 +1,156 @@
+package webserver_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"web-server-go-docker/pkg/webserver"
+)
+
+// ordersModule - пример модуля сервиса, построенного на сервере
+type ordersModule struct{}
+
+func (ordersModule) Name() string { return "orders" }
+
+func (ordersModule) Register(s *webserver.Server) error {
+	api := s.Group("/api/v1")
+	api.HandleFunc(http.MethodGet, "/orders", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}, webserver.WithDescription("List orders"))
+
+	s.AddHealthCheck("orders-db", func(ctx context.Context) error { return nil })
+	s.OnShutdown(func(ctx context.Context) error { return nil })
+	return nil
+}
+
+func Example() {
+	cfg, err := webserver.LoadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	srv, err := webserver.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := srv.Register(ordersModule{}); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, route := range srv.Routes() {
+		if route.Path == "/api/v1/orders" {
+			fmt.Println(route.Method, route.Path, "-", route.Description)
+		}
+	}
+	// Output: GET /api/v1/orders - List orders
+}
+
+// checkoutModule считает оформленные заказы в пользовательской метрике
+type checkoutModule struct{}
+
+func (checkoutModule) Name() string { return "checkout" }
+
+func (checkoutModule) Register(s *webserver.Server) error {
+	// Metrics возвращает nil, если метрики отключены (METRICS_ENABLED=false);
+	// NewCounter у nil возвращает счетчик, который ничего не записывает
+	created, err := s.Metrics().NewCounter(webserver.MetricOpts{
+		Name:   "orders_created_total",
+		Help:   "Number of created orders.",
+		Labels: []string{"payment"},
+	})
+	if err != nil {
+		return err
+	}
+
+	s.HandleFunc(http.MethodPost, "/api/v1/checkout", func(w http.ResponseWriter, r *http.Request) {
+		created.Inc(r.URL.Query().Get("payment"))
+		w.WriteHeader(http.StatusCreated)
+	})
+	return nil
+}
+
+func Example_customMetrics() {
+	cfg, err := webserver.LoadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg.Metrics.Namespace = "shop"
+
+	srv, err := webserver.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := srv.Register(checkoutModule{}); err != nil {
+		log.Fatal(err)
+	}
+
+	srv.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/checkout?payment=card", nil))
+
+	scrape := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(scrape, httptest.NewRequest(http.MethodGet, cfg.Metrics.Path, nil))
+	for _, line := range strings.Split(scrape.Body.String(), "\n") {
+		if strings.HasPrefix(line, "shop_orders_created_total") {
+			fmt.Println(line)
+		}
+	}
+	// Output: shop_orders_created_total{payment="card"} 1
+}
+
+func Example_apiKeys() {
+	// В файле хранятся только хеши ключей: ./main apikey generate печатает ключ и запись
+	dir, err := os.MkdirTemp("", "apikeys")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keys := "keys:\n" +
+		"  - id: billing-worker\n" +
+		"    owner: billing-team\n" +
+		"    hash: sha256:" + fmt.Sprintf("%x", sha256.Sum256([]byte("example-key"))) + "\n" +
+		"    scopes: [orders:write]\n"
+	if err := os.WriteFile(filepath.Join(dir, "billing.yaml"), []byte(keys), 0o600); err != nil {
+		log.Fatal(err)
+	}
+
+	cfg, err := webserver.LoadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg.APIKeys.File = dir
+
+	srv, err := webserver.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	srv.HandleFunc(http.MethodPost, "/api/v1/orders", func(w http.ResponseWriter, r *http.Request) {
+		key := webserver.APIKeyFromContext(r.Context())
+		fmt.Fprintf(w, "created by %s", key.Owner)
+	}, webserver.WithAPIKey("orders:write"))
+
+	for _, header := range []string{"", "Bearer wrong-key", "Bearer example-key"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		if rec.Code == http.StatusOK {
+			fmt.Println(rec.Code, rec.Body.String())
+		} else {
+			fmt.Println(rec.Code)
+		}
+	}
+	// Output:
+	// 401
+	// 401
+	// 200 created by billing-team
+}
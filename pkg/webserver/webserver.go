@@ -0,0 +1,115 @@
+// Package webserver - публичный API для сервисов, встраивающих этот сервер.
+//
+// Пакеты internal/ недоступны для импорта из других модулей, поэтому здесь
+// реэкспортируются типы и функции, необходимые для подключения своих маршрутов,
+// проверок готовности, метрик и хуков остановки.
+package webserver
+
+import (
+	"context"
+	"net/http"
+
+	"web-server-go-docker/internal/apikey"
+	"web-server-go-docker/internal/config"
+	"web-server-go-docker/internal/handlers"
+	"web-server-go-docker/internal/metrics"
+	"web-server-go-docker/internal/middleware"
+	"web-server-go-docker/internal/openapi"
+	"web-server-go-docker/internal/server"
+)
+
+type (
+	// Server - HTTP сервер
+	Server = server.Server
+	// Config - конфигурация сервера
+	Config = config.Config
+	// Module - подключаемая часть приложения
+	Module = server.Module
+	// Group - группа маршрутов с общим префиксом
+	Group = server.Group
+	// RouteOption настраивает маршрут или группу
+	RouteOption = server.RouteOption
+	// RouteInfo описывает зарегистрированный маршрут
+	RouteInfo = server.RouteInfo
+	// HealthCheck - проверка зависимости для /ready
+	HealthCheck = server.HealthCheck
+	// ShutdownHook вызывается при остановке сервера
+	ShutdownHook = server.ShutdownHook
+	// Middleware - интерфейс middleware
+	Middleware = middleware.Middleware
+	// Operation описывает маршрут для OpenAPI документа
+	Operation = openapi.Operation
+	// Condition - условие применения middleware
+	Condition = middleware.Condition
+	// Problem - ответ об ошибке в формате RFC 7807
+	Problem = handlers.Problem
+	// Encoder - формат ответа для content negotiation
+	Encoder = handlers.Encoder
+	// Metrics - Prometheus метрики сервера (Server.Metrics)
+	Metrics = metrics.Metrics
+	// MetricOpts описывает пользовательскую метрику для Metrics.NewCounter и NewGauge
+	MetricOpts = metrics.Opts
+	// HistogramOpts описывает пользовательскую гистограмму для Metrics.NewHistogram
+	HistogramOpts = metrics.HistogramOpts
+	// Counter, Gauge и Histogram - пользовательские метрики
+	Counter   = metrics.Counter
+	Gauge     = metrics.Gauge
+	Histogram = metrics.Histogram
+	// APIKey - описание API ключа запроса (APIKeyFromContext)
+	APIKey = apikey.Key
+)
+
+// New создает сервер со встроенными маршрутами
+func New(cfg *Config) (*Server, error) { return server.New(cfg) }
+
+// LoadConfig загружает конфигурацию из переменных окружения
+func LoadConfig() (*Config, error) { return config.Load() }
+
+// WithDescription задает описание маршрута
+func WithDescription(description string) RouteOption { return server.WithDescription(description) }
+
+// WithOperation описывает маршрут для OpenAPI документа
+func WithOperation(op *Operation) RouteOption { return server.WithOperation(op) }
+
+// NewOperation создает описание операции
+func NewOperation(summary string) *Operation { return openapi.NewOperation(summary) }
+
+// WithMiddleware добавляет middleware к маршруту или группе
+func WithMiddleware(mws ...Middleware) RouteOption { return server.WithMiddleware(mws...) }
+
+// WithAPIKey требует API ключ со scopes для маршрута или группы
+func WithAPIKey(scopes ...string) RouteOption { return server.WithAPIKey(scopes...) }
+
+// APIKeyFromContext возвращает API ключ, которым аутентифицирован запрос
+func APIKeyFromContext(ctx context.Context) *APIKey { return apikey.FromContext(ctx) }
+
+// MiddlewareFunc создает именованный Middleware из функции
+func MiddlewareFunc(name string, fn func(http.Handler) http.Handler) Middleware {
+	return middleware.NewFunc(name, fn)
+}
+
+// When применяет middleware только при выполнении условия
+func When(m Middleware, cond Condition) Middleware { return middleware.When(m, cond) }
+
+// Unless пропускает middleware при выполнении условия
+func Unless(m Middleware, cond Condition) Middleware { return middleware.Unless(m, cond) }
+
+// PathIs совпадает, если путь запроса равен одному из указанных
+func PathIs(paths ...string) Condition { return middleware.PathIs(paths...) }
+
+// PathPrefix совпадает, если путь запроса начинается с одного из префиксов
+func PathPrefix(prefixes ...string) Condition { return middleware.PathPrefix(prefixes...) }
+
+// MethodIs совпадает, если метод запроса равен одному из указанных
+func MethodIs(methods ...string) Condition { return middleware.MethodIs(methods...) }
+
+// Any совпадает, если совпадает хотя бы одно из условий
+func Any(conds ...Condition) Condition { return middleware.Any(conds...) }
+
+// NewProblem создает ответ об ошибке
+func NewProblem(status int, detail string) *Problem { return handlers.NewProblem(status, detail) }
+
+// WriteProblem отправляет ответ об ошибке
+func WriteProblem(w http.ResponseWriter, r *http.Request, p *Problem) {
+	handlers.WriteProblem(w, r, p)
+}